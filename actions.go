@@ -0,0 +1,411 @@
+package main
+
+import (
+	"github.com/mozvip/gomics/crop"
+	"github.com/mozvip/gomics/filters"
+)
+
+// Action is a command of the reader which can be bound to keys and mouse buttons
+type Action struct {
+	Name        string
+	Category    string
+	Description string
+	// Defaults are the bindings used when the action is not configured in config.yml
+	Defaults []string
+	// Repeat runs the action again while its key is held down
+	Repeat bool
+	// NoHistory actions are not recorded in the edit history : the actions which do not change the album, undo, redo and
+	// the editors recording their own edit when they are closed. The navigation actions are never recorded.
+	NoHistory bool
+
+	Run func(g *GogoReader, repeated bool)
+}
+
+const (
+	categoryNavigation = "Navigation"
+	categoryDisplay    = "Display"
+	categoryEdition    = "Page edition"
+	categoryAlbum      = "Album"
+)
+
+// actions lists all the actions, grouped by category
+var actions = []*Action{
+	{Name: "previous-page", Category: categoryNavigation, Description: "Previous page", Defaults: []string{"PageUp", wheelUp, "GamepadLeftBumper", "GamepadDpadLeft"},
+		Run: func(g *GogoReader, repeated bool) {
+			if g.guided.active {
+				g.previousPanel()
+			} else if album.CurrentViewIndex > 0 {
+				g.PreviousPage()
+			}
+		}},
+	{Name: "next-page", Category: categoryNavigation, Description: "Next page", Defaults: []string{"PageDown", wheelDown, "GamepadRightBumper", "GamepadDpadRight"},
+		Run: func(g *GogoReader, repeated bool) {
+			if g.guided.active {
+				g.nextPanel()
+			} else if album.CurrentViewIndex < len(album.Views)-1 {
+				g.NextPage()
+			}
+		}},
+	{Name: "first-page", Category: categoryNavigation, Description: "Go to the first page", Defaults: []string{"Home"},
+		Run: func(g *GogoReader, repeated bool) { g.goTo(0) }},
+	{Name: "last-page", Category: categoryNavigation, Description: "Go to the last page", Defaults: []string{"End"},
+		Run: func(g *GogoReader, repeated bool) { g.goTo(len(album.Views) - 1) }},
+	{Name: "go-to-page", Category: categoryNavigation, Description: "Go to a page by its number", Defaults: []string{"Ctrl+G"},
+		Run: func(g *GogoReader, repeated bool) { g.openGoToPageDialog() }},
+	{Name: "thumbnails", Category: categoryNavigation, Description: "Show / hide the thumbnails of all pages", Defaults: []string{"T", "GamepadY"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleGridDisplay() }},
+	{Name: "toggle-bookmark", Category: categoryNavigation, Description: "Bookmark the current page, or remove its bookmark", Defaults: []string{"M"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleBookmark() }},
+	{Name: "bookmarks", Category: categoryNavigation, Description: "Show the bookmarks of the album", Defaults: []string{"K"},
+		Run: func(g *GogoReader, repeated bool) {
+			if len(album.Bookmarks) > 0 {
+				g.toggleBookmarksDisplay()
+			} else {
+				g.notify("No bookmark in this album")
+			}
+		}},
+
+	{Name: "slideshow", Category: categoryNavigation, Description: "Start / stop the slideshow", Defaults: []string{"S", "GamepadX"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleSlideshow() }},
+
+	{Name: "fullscreen", Category: categoryDisplay, Description: "Toggle fullscreen", Defaults: []string{"F11", "F", "GamepadStart"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			if !g.preferences.FullScreen {
+				// save the current size of the window
+				g.preferences.WindowedSize = g.win.Bounds().Size()
+			}
+			g.preferences.FullScreen = !g.preferences.FullScreen
+			g.ToggleFullScreen()
+		}},
+	{Name: "zoom", Category: categoryDisplay, Description: "Toggle zoom to the width of the window", Defaults: []string{"GamepadA"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			g.Zoom = !g.Zoom
+			g.ZoomPositionX = g.win.Bounds().Center().X
+		}},
+	{Name: "guided-view", Category: categoryDisplay, Description: "Toggle the guided view, reading the pages panel by panel", Defaults: []string{"P"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.toggleGuidedView() }},
+	{Name: "info", Category: categoryDisplay, Description: "Show / hide page information", Defaults: []string{"I"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.toggleInfoDisplay() }},
+	{Name: "smooth", Category: categoryDisplay, Description: "Toggle between smooth and pixelated scaling", Defaults: []string{"F2"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			g.win.SetSmooth(!g.win.Smooth())
+			g.notify("Smooth scaling %s", onOff(g.win.Smooth()))
+			g.needsRefresh = true
+		}},
+	{Name: "filter", Category: categoryDisplay, Description: "Change the filter used to scale the images to the size of the window", Defaults: []string{"F3"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			g.preferences.Filter = g.preferences.Filter.Next()
+			g.notify("Scaling filter : %s", g.preferences.Filter)
+			g.needsRefresh = true
+		}},
+	{Name: "transition", Category: categoryDisplay, Description: "Change the page transition : none, fade, slide or curl", Defaults: []string{"Shift+T"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			transitions := []string{TransitionNone, TransitionFade, TransitionSlide, TransitionCurl}
+			next := 0
+			for index, transition := range transitions {
+				if transition == g.preferences.Transition {
+					next = (index + 1) % len(transitions)
+				}
+			}
+			g.preferences.Transition = transitions[next]
+			g.notify("Page transition : %s", g.preferences.Transition)
+		}},
+	{Name: "grayscale", Category: categoryDisplay, Description: "Toggle gray scale for the whole album", Defaults: []string{"G"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.GrayScale = !album.GrayScale
+			g.notify("Grayscale %s", onOff(album.GrayScale))
+			g.needsRefresh = true
+		}},
+	{Name: "auto-levels", Category: categoryDisplay, Description: "Toggle automatic levels correction for the whole album", Defaults: []string{"W"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.AutoLevels = !album.AutoLevels
+			g.notify("Auto levels %s", onOff(album.AutoLevels))
+			g.needsRefresh = true
+		}},
+	{Name: "page-auto-levels", Category: categoryDisplay, Description: "Toggle automatic levels correction for the current page", Defaults: []string{"Shift+W"},
+		Run: func(g *GogoReader, repeated bool) {
+			view := album.GetCurrentView()
+			view.AutoLevelsOverride = !view.AutoLevelsOverride
+			g.notify("Auto levels %s for this page", onOff(album.AutoLevels != view.AutoLevelsOverride))
+			g.needsRefresh = true
+		}},
+	{Name: "paper-whitening", Category: categoryDisplay, Description: "Toggle paper whitening, turning the color of the paper to white with auto levels", Defaults: []string{"Ctrl+W"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.PaperWhitening = !album.PaperWhitening
+			g.notify("Paper whitening %s", onOff(album.PaperWhitening))
+			g.needsRefresh = true
+		}},
+	{Name: "theme", Category: categoryDisplay, Description: "Change the theme of the album : none, invert, sepia or warm (less blue light)", Defaults: []string{"N"},
+		Run: func(g *GogoReader, repeated bool) {
+			next := 0
+			for index, theme := range filters.Themes {
+				if theme == album.Theme {
+					next = (index + 1) % len(filters.Themes)
+				}
+			}
+			album.Theme = filters.Themes[next]
+			g.notify("Theme : %s", album.Theme)
+			g.needsRefresh = true
+		}},
+	{Name: "color-vision", Category: categoryDisplay, Description: "Change the color vision deficiency the colors are corrected for : protan, deutan or tritan", Defaults: []string{"C"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			next := 0
+			for index, deficiency := range filters.Deficiencies {
+				if deficiency == g.preferences.ColorVision {
+					next = (index + 1) % len(filters.Deficiencies)
+				}
+			}
+			g.preferences.ColorVision = filters.Deficiencies[next]
+			g.notify("Color vision deficiency : %s", g.preferences.ColorVision)
+			g.needsRefresh = true
+		}},
+	{Name: "color-vision-simulation", Category: categoryDisplay, Description: "Toggle between correcting the colors and simulating the color vision deficiency", Defaults: []string{"Shift+C"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			g.preferences.ColorVisionSimulation = !g.preferences.ColorVisionSimulation
+			if g.preferences.ColorVisionSimulation {
+				g.notify("Simulation of the color vision deficiency")
+			} else {
+				g.notify("Correction of the color vision deficiency")
+			}
+			g.needsRefresh = true
+		}},
+	{Name: "adjustments", Category: categoryDisplay, Description: "Adjust brightness, contrast, gamma, saturation and sharpness", Defaults: []string{"A"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.toggleAdjustmentsDisplay() }},
+	{Name: "remove-borders", Category: categoryDisplay, Description: "Toggle automatic border removal", Defaults: []string{"B"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			g.preferences.RemoveBorders = !g.preferences.RemoveBorders
+			g.notify("Borders removal %s", onOff(g.preferences.RemoveBorders))
+			g.needsRefresh = true
+		}},
+	{Name: "crop-comparator", Category: categoryDisplay, Description: "Change how colors are compared by the border removal of the album : rgb, cie76 or ciede2000", Defaults: []string{"Ctrl+B"},
+		Run: func(g *GogoReader, repeated bool) {
+			options := album.CropOptions(g.preferences.Crop)
+			next := 0
+			for index, comparator := range crop.Comparators {
+				if comparator == options.Comparator {
+					next = (index + 1) % len(crop.Comparators)
+				}
+			}
+			options.Comparator = crop.Comparators[next]
+			album.Crop = &options
+			g.notify("Border removal comparator : %s", options.Comparator)
+			g.needsRefresh = true
+		}},
+	{Name: "consistent-crop", Category: categoryDisplay, Description: "Crop all the pages of the album the same way, or remove this crop", Defaults: []string{"Alt+B"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleAlbumCrop() }},
+	{Name: "remove-page-borders", Category: categoryDisplay, Description: "Toggle automatic border removal for the current page", Defaults: []string{"Shift+B"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.GetCurrentView().ToggleBorder(g.preferences.RemoveBorders)
+			g.notify("Borders removal %s for this page", onOff(album.GetCurrentView().RemoveBorders))
+			g.needsRefresh = true
+		}},
+
+	{Name: "crop-top", Category: categoryEdition, Description: "Crop the top of the page", Defaults: []string{"Up"}, Repeat: true,
+		Run: func(g *GogoReader, repeated bool) { album.GetCurrentView().Images[0].Top += g.cropSpeed(repeated) }},
+	{Name: "crop-bottom", Category: categoryEdition, Description: "Crop the bottom of the page", Defaults: []string{"Down"}, Repeat: true,
+		Run: func(g *GogoReader, repeated bool) { album.GetCurrentView().Images[0].Bottom += g.cropSpeed(repeated) }},
+	{Name: "crop-left", Category: categoryEdition, Description: "Crop the left of the page", Defaults: []string{"Left"}, Repeat: true,
+		Run: func(g *GogoReader, repeated bool) { album.GetCurrentView().Images[0].Left += g.cropSpeed(repeated) }},
+	{Name: "crop-right", Category: categoryEdition, Description: "Crop the right of the page", Defaults: []string{"Right"}, Repeat: true,
+		Run: func(g *GogoReader, repeated bool) { album.GetCurrentView().Images[0].Right += g.cropSpeed(repeated) }},
+	{Name: "crop", Category: categoryEdition, Description: "Crop the page with the mouse", Defaults: []string{"X"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.startCropEditor() }},
+	{Name: "rotate-left", Category: categoryEdition, Description: "Rotate 90 degrees left", Defaults: []string{"L"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.GetCurrentView().RotateLeft()
+			g.needsRefresh = true
+		}},
+	{Name: "rotate-right", Category: categoryEdition, Description: "Rotate 90 degrees right", Defaults: []string{"R"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.GetCurrentView().RotateRight()
+			g.needsRefresh = true
+		}},
+	{Name: "decrease-angle", Category: categoryEdition, Description: "Decrement current page angle", Defaults: []string{"Minus", "KPSubtract"},
+		Run: func(g *GogoReader, repeated bool) {
+			view := album.GetCurrentView()
+			view.setAngle(view.angle() - 0.05)
+			g.needsRefresh = true
+		}},
+	{Name: "increase-angle", Category: categoryEdition, Description: "Increment current page angle", Defaults: []string{"Period", "KPAdd"},
+		Run: func(g *GogoReader, repeated bool) {
+			view := album.GetCurrentView()
+			view.setAngle(view.angle() + 0.05)
+			g.needsRefresh = true
+		}},
+	{Name: "reset-angle", Category: categoryEdition, Description: "Reset current page angle", Defaults: []string{"KPDivide"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.GetCurrentView().setAngle(0)
+			g.needsRefresh = true
+		}},
+	{Name: "deskew", Category: categoryEdition, Description: "Straighten the current page with the angle found by the skew detection", Defaults: []string{"Ctrl+D"},
+		Run: func(g *GogoReader, repeated bool) { g.acceptSkew() }},
+	{Name: "auto-deskew", Category: categoryEdition, Description: "Toggle the automatic straightening of the pages whose angle was not set", Defaults: []string{"Alt+D"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.AutoDeskew = !album.AutoDeskew
+			for _, view := range album.Views {
+				if !view.AngleSet {
+					view.ClearPanels()
+				}
+			}
+			g.notify("Automatic deskew %s", onOff(album.AutoDeskew))
+			g.needsRefresh = true
+		}},
+	{Name: "double-page", Category: categoryEdition, Description: "Toggle between single image / double image for the current page", Defaults: []string{"D"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleDoublePage() }},
+	{Name: "split-spread", Category: categoryEdition, Description: "Split the image of the current page into its two pages, or merge back a split spread", Defaults: []string{"Shift+D"},
+		Run: func(g *GogoReader, repeated bool) { g.toggleSpread() }},
+	{Name: "increase-overlap", Category: categoryEdition, Description: "Extend the pages of a split spread past the fold", Defaults: []string{"Shift+Period"},
+		Run: func(g *GogoReader, repeated bool) { g.changeOverlap(overlapStep) }},
+	{Name: "decrease-overlap", Category: categoryEdition, Description: "Reduce the part of the pages of a split spread past the fold", Defaults: []string{"Shift+Minus"},
+		Run: func(g *GogoReader, repeated bool) { g.changeOverlap(-overlapStep) }},
+	{Name: "analyze-pages", Category: categoryEdition, Description: "Look for blank and duplicated pages, flagged in the thumbnails", Defaults: []string{"Ctrl+A"},
+		Run: func(g *GogoReader, repeated bool) { g.startPageAnalysis() }},
+	{Name: "delete-page", Category: categoryEdition, Description: "Hide the current page", Defaults: []string{"Delete"},
+		Run: func(g *GogoReader, repeated bool) { g.deletePage() }},
+	{Name: "hidden-pages", Category: categoryEdition, Description: "Show the hidden pages, click on a page to restore it", Defaults: []string{"H"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.toggleHiddenDisplay() }},
+
+	{Name: "merge-panel", Category: categoryEdition, Description: "Merge the current panel with the next one (guided view)", Defaults: []string{"Shift+M"},
+		Run: func(g *GogoReader, repeated bool) { g.mergePanel() }},
+	{Name: "delete-panel", Category: categoryEdition, Description: "Remove the current panel (guided view)", Defaults: []string{"Shift+Delete"},
+		Run: func(g *GogoReader, repeated bool) { g.deletePanel() }},
+	{Name: "detect-panels", Category: categoryEdition, Description: "Detect the panels of the current page again (guided view)", Defaults: []string{"Shift+P"},
+		Run: func(g *GogoReader, repeated bool) { g.detectViewPanels() }},
+
+	{Name: "right-to-left", Category: categoryAlbum, Description: "Toggle right to left reading (manga)", Defaults: []string{"Ctrl+R"},
+		Run: func(g *GogoReader, repeated bool) {
+			album.RightToLeft = !album.RightToLeft
+			g.notify("Right to left reading %s", onOff(album.RightToLeft))
+			g.needsRefresh = true
+		}},
+	{Name: "reset", Category: categoryAlbum, Description: "Reset album settings, all the pages of the archive are displayed again", Defaults: []string{"Backspace"},
+		Run: func(g *GogoReader, repeated bool) {
+			images, err := listImages(comicBook)
+			if err != nil {
+				g.notifyError(err)
+				return
+			}
+			album.Reset(images)
+			g.notify("Album settings reset")
+			g.needsRefresh = true
+		}},
+	{Name: "undo", Category: categoryAlbum, Description: "Undo the last change of the album", Defaults: []string{"Ctrl+Z"}, Repeat: true, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.undo() }},
+	{Name: "redo", Category: categoryAlbum, Description: "Redo the last change undone", Defaults: []string{"Ctrl+Y", "Ctrl+Shift+Z"}, Repeat: true, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.redo() }},
+	{Name: "save", Category: categoryAlbum, Description: "Save the settings of the album now", Defaults: []string{"Ctrl+S"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) {
+			if err := saveConfiguration(g.preferences); err != nil {
+				g.notifyError(err)
+			} else {
+				g.notify("Configuration saved")
+			}
+		}},
+	{Name: "help", Category: categoryAlbum, Description: "Show / hide this help", Defaults: []string{"F1", "Shift+Slash", "GamepadBack"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { g.toggleHelpDisplay() }},
+	{Name: "quit", Category: categoryAlbum, Description: "Quit gogoreader", Defaults: []string{"Escape", "Q"}, NoHistory: true,
+		Run: func(g *GogoReader, repeated bool) { AppQuit(g.preferences) }},
+}
+
+// findAction returns the action with the given name, or nil
+func findAction(name string) *Action {
+	for _, action := range actions {
+		if action.Name == name {
+			return action
+		}
+	}
+	return nil
+}
+
+// runActions runs the actions whose bindings were triggered, mouse bindings are
+// ignored when the mouse was already used by another part of the interface
+func (g *GogoReader) runActions(mouseUsed bool) {
+	for _, action := range actions {
+		for _, binding := range g.keyMap.Bindings(action.Name) {
+			if mouseUsed && binding.IsMouse() {
+				continue
+			}
+			if binding.Triggered(g.win, action.Repeat) {
+				g.run(action, binding.Repeated(g.win))
+				break
+			}
+		}
+		if g.overlayDisplayed() {
+			// the overlay handles the input from now on
+			return
+		}
+	}
+}
+
+// actionTriggered returns true when one of the bindings of an action was activated, used by the overlays
+// to close themselves with the keys opening them
+func (g *GogoReader) actionTriggered(name string) bool {
+	for _, binding := range g.keyMap.Bindings(name) {
+		if binding.Triggered(g.win, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs an action, recording its changes to the album in the edit history
+func (g *GogoReader) run(action *Action, repeated bool) {
+	if action.Category == categoryNavigation || action.NoHistory {
+		action.Run(g, repeated)
+		return
+	}
+	g.record(action.Name, repeated, func() { action.Run(g, repeated) })
+}
+
+// overlayDisplayed returns true when an overlay (thumbnails, dialog, bookmarks, help, adjustments) takes the input instead of the actions
+func (g *GogoReader) overlayDisplayed() bool {
+	return g.gridDisplay || g.dialog != nil || g.bookmarksDisplay || g.helpDisplay || g.adjustmentsDisplay
+}
+
+// cropSpeed returns the number of pixels to crop at each key press
+func (g *GogoReader) cropSpeed(repeated bool) int {
+	g.needsRefresh = true
+	if repeated {
+		return 2
+	}
+	return 1
+}
+
+// deletePage hides the images of the current view, they can be restored from the hidden pages
+func (g *GogoReader) deletePage() {
+	if len(album.Views) <= 1 {
+		return
+	}
+	g.notify("Page %d hidden", album.CurrentViewIndex+1)
+	for _, img := range append([]*ImageData(nil), album.GetCurrentView().Images...) {
+		album.hideImage(img)
+	}
+	g.needsRefresh = true
+}
+
+// restorePage displays a hidden image again
+func (g *GogoReader) restorePage(imgData *ImageData) {
+	viewIndex := album.restoreImage(imgData)
+	g.notify("Page %d restored", viewIndex+1)
+	g.needsRefresh = true
+}
+
+func (g *GogoReader) toggleDoublePage() {
+	if len(album.GetCurrentView().Images) == 1 && album.CurrentViewIndex < len(album.Views)-1 {
+		// only if we have a page after the current one
+		album.GetCurrentView().Images = append(album.GetCurrentView().Images, album.Views[album.CurrentViewIndex+1].Images...)
+		album.Views = append(album.Views[:album.CurrentViewIndex+1], album.Views[album.CurrentViewIndex+2:]...)
+	} else if len(album.GetCurrentView().Images) > 1 {
+		// create a new page with only the second image
+		newPage := ViewData{Images: album.GetCurrentView().Images[1:]}
+		// only keep the first image on the current page
+		album.GetCurrentView().Images = album.GetCurrentView().Images[:1]
+
+		// allocate one more page
+		album.Views = append(album.Views[:album.CurrentViewIndex+1], album.Views[album.CurrentViewIndex:]...)
+		// next page is the new page
+		album.Views[album.CurrentViewIndex+1] = &newPage
+	}
+	g.notify("%d image(s) on this page", len(album.GetCurrentView().Images))
+	g.needsRefresh = true
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+// Adjustments are the color corrections applied to the images, zero values leave the images unchanged
+type Adjustments struct {
+	// Brightness, Contrast and Saturation are percentages, from -100 to 100
+	Brightness float64 `yaml:",omitempty"`
+	Contrast   float64 `yaml:",omitempty"`
+	Saturation float64 `yaml:",omitempty"`
+	// Gamma is added to the gamma of 1.0 of the images
+	Gamma float64 `yaml:",omitempty"`
+	// Sharpen is the sigma of the sharpening, 0 to disable it
+	Sharpen float64 `yaml:",omitempty"`
+}
+
+// IsZero returns true when the adjustments do not change the images
+func (a Adjustments) IsZero() bool {
+	return a == Adjustments{}
+}
+
+// Add returns the sum of two adjustments, used to apply the adjustments of a view on top of the adjustments of the album
+func (a Adjustments) Add(other Adjustments) Adjustments {
+	return Adjustments{
+		Brightness: a.Brightness + other.Brightness,
+		Contrast:   a.Contrast + other.Contrast,
+		Saturation: a.Saturation + other.Saturation,
+		Gamma:      a.Gamma + other.Gamma,
+		Sharpen:    a.Sharpen + other.Sharpen,
+	}
+}
+
+// Apply returns the adjusted image
+func (a Adjustments) Apply(img image.Image) image.Image {
+	if a.IsZero() {
+		return img
+	}
+	if a.Brightness != 0 {
+		img = imaging.AdjustBrightness(img, a.Brightness)
+	}
+	if a.Contrast != 0 {
+		img = imaging.AdjustContrast(img, a.Contrast)
+	}
+	if a.Gamma != 0 {
+		img = imaging.AdjustGamma(img, math.Max(0.1, 1+a.Gamma))
+	}
+	if a.Saturation != 0 {
+		img = imaging.AdjustSaturation(img, a.Saturation)
+	}
+	if a.Sharpen > 0 {
+		img = imaging.Sharpen(img, a.Sharpen)
+	}
+	return img
+}
+
+// adjustment is a setting of the adjustments which can be changed on screen
+type adjustment struct {
+	name     string
+	step     float64
+	min, max float64
+	value    func(a *Adjustments) *float64
+}
+
+var adjustmentSettings = []adjustment{
+	{name: "Brightness", step: 5, min: -100, max: 100, value: func(a *Adjustments) *float64 { return &a.Brightness }},
+	{name: "Contrast", step: 5, min: -100, max: 100, value: func(a *Adjustments) *float64 { return &a.Contrast }},
+	{name: "Gamma", step: 0.05, min: -0.9, max: 2, value: func(a *Adjustments) *float64 { return &a.Gamma }},
+	{name: "Saturation", step: 5, min: -100, max: 100, value: func(a *Adjustments) *float64 { return &a.Saturation }},
+	{name: "Sharpen", step: 0.25, min: 0, max: 5, value: func(a *Adjustments) *float64 { return &a.Sharpen }},
+}
+
+// adjustmentsTarget returns the adjustments currently edited : those of the album or those of the current view
+func (g *GogoReader) adjustmentsTarget() *Adjustments {
+	if g.adjustPage {
+		return &album.GetCurrentView().Adjustments
+	}
+	return &album.Adjustments
+}
+
+func (g *GogoReader) toggleAdjustmentsDisplay() {
+	g.adjustmentsDisplay = !g.adjustmentsDisplay
+	if g.adjustmentsDisplay {
+		albumHistory.begin("adjustments")
+	} else {
+		albumHistory.commit(g.preferences.HistoryDepth)
+	}
+}
+
+func (g *GogoReader) updateAdjustments() {
+	if g.win.JustPressed(pixelgl.KeyEscape) || g.win.JustPressed(pixelgl.KeyEnter) || g.win.JustPressed(pixelgl.KeyKPEnter) {
+		g.adjustmentsDisplay = false
+		albumHistory.commit(g.preferences.HistoryDepth)
+		return
+	}
+
+	pressed := func(button pixelgl.Button) bool {
+		return g.win.JustPressed(button) || g.win.Repeated(button)
+	}
+	if pressed(pixelgl.KeyDown) && g.adjustSelection < len(adjustmentSettings)-1 {
+		g.adjustSelection++
+	}
+	if pressed(pixelgl.KeyUp) && g.adjustSelection > 0 {
+		g.adjustSelection--
+	}
+	if g.win.JustPressed(pixelgl.KeyTab) {
+		g.adjustPage = !g.adjustPage
+	}
+
+	setting := adjustmentSettings[g.adjustSelection]
+	value := setting.value(g.adjustmentsTarget())
+	previous := *value
+	if pressed(pixelgl.KeyRight) || g.win.MouseScroll().Y > 0 {
+		*value += setting.step
+	}
+	if pressed(pixelgl.KeyLeft) || g.win.MouseScroll().Y < 0 {
+		*value -= setting.step
+	}
+	if g.win.JustPressed(pixelgl.Key0) || g.win.JustPressed(pixelgl.KeyKP0) {
+		*value = 0
+	}
+	if g.win.JustPressed(pixelgl.KeyBackspace) {
+		*g.adjustmentsTarget() = Adjustments{}
+	}
+	// rounding avoids accumulating errors on the steps
+	*value = math.Round(math.Max(setting.min, math.Min(setting.max, *value))*100) / 100
+	if *value != previous || g.win.JustPressed(pixelgl.KeyBackspace) {
+		g.needsRefresh = true
+	}
+}
+
+const adjustmentsTextScale = 2.0
+
+func (g *GogoReader) drawAdjustments() {
+	if !g.adjustmentsDisplay {
+		return
+	}
+
+	lineHeight := fontAtlas.LineHeight() * adjustmentsTextScale
+	width := g.size.X / 2
+	left := g.size.X / 4
+	top := lineHeight * float64(len(adjustmentSettings)+3)
+
+	imd := imdraw.New(nil)
+	imd.Color = color.RGBA{30, 30, 30, 220}
+	imd.Push(pixel.V(left-10, 10), pixel.V(left+width+10, top+10))
+	imd.Rectangle(0)
+
+	adjustments := g.adjustmentsTarget()
+	barLeft, barWidth := left+width/2, width/2-10
+	for index, setting := range adjustmentSettings {
+		y := top - lineHeight*float64(index+2)
+		if index == g.adjustSelection {
+			imd.Color = color.RGBA{90, 140, 200, 220}
+			imd.Push(pixel.V(left, y), pixel.V(left+width, y+lineHeight))
+			imd.Rectangle(0)
+		}
+		// the bar goes from the minimum to the value of the setting
+		ratio := (*setting.value(adjustments) - setting.min) / (setting.max - setting.min)
+		imd.Color = color.RGBA{80, 80, 80, 255}
+		imd.Push(pixel.V(barLeft, y+lineHeight/3), pixel.V(barLeft+barWidth, y+lineHeight*2/3))
+		imd.Rectangle(0)
+		imd.Color = color.RGBA{220, 220, 220, 255}
+		imd.Push(pixel.V(barLeft, y+lineHeight/3), pixel.V(barLeft+barWidth*ratio, y+lineHeight*2/3))
+		imd.Rectangle(0)
+	}
+	imd.Draw(g.win)
+
+	scope := "album"
+	if g.adjustPage {
+		scope = fmt.Sprintf("page %d, added to the album", album.CurrentViewIndex+1)
+	}
+	adjustmentsText := text.New(pixel.ZV, fontAtlas)
+	fmt.Fprintf(adjustmentsText, "Adjustments of the %s (Tab to change)\n", scope)
+	for _, setting := range adjustmentSettings {
+		fmt.Fprintf(adjustmentsText, "  %-12s %6.2f\n", setting.name, *setting.value(adjustments))
+	}
+	fmt.Fprintln(adjustmentsText, "Left / Right to change, 0 to reset, Backspace to reset all")
+	adjustmentsText.Draw(g.win, pixel.IM.Scaled(pixel.ZV, adjustmentsTextScale).Moved(pixel.V(left+5, top-lineHeight+fontAtlas.Descent()*adjustmentsTextScale)))
+}
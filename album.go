@@ -1,6 +1,12 @@
 package main
 
-import "path"
+import (
+	"path"
+	"sort"
+
+	"github.com/mozvip/gomics/crop"
+	"github.com/mozvip/gomics/filters"
+)
 
 type Rotation uint8
 
@@ -10,13 +16,35 @@ const (
 	Right
 )
 
+// Bookmark is a named place in an album, identified by the first image of the bookmarked view
+type Bookmark struct {
+	Name     string
+	FileName string
+}
+
 type Album struct {
 	MD5              string
+	FileName         string
 	CurrentViewIndex int
 	Views            []*ViewData
-	Images           []*ImageData `json:"-"`
-	GrayScale        bool
-	RemoveBorders    bool
+	// Images are all the images of the archive in reading order, the hidden ones included, shared with the views
+	Images    []*ImageData
+	GrayScale bool
+	// AutoLevels corrects the black and white points of the images, PaperWhitening also turns the color of the paper to white
+	AutoLevels     bool
+	PaperWhitening bool
+	// AutoDeskew straightens the pages whose angle was not set with the angle found by the skew detection
+	AutoDeskew bool `yaml:",omitempty"`
+	// Theme changes the colors of the pages, for reading at night
+	Theme         filters.Theme `yaml:",omitempty"`
+	Adjustments   Adjustments   `yaml:",omitempty"`
+	RemoveBorders bool
+	// Crop replaces the border removal options of the preferences for this album
+	Crop *crop.Options `yaml:",omitempty"`
+	// ConsistentCrop gives the same size to all the pages
+	ConsistentCrop *AlbumCrop `yaml:",omitempty"`
+	RightToLeft    bool
+	Bookmarks      []Bookmark
 }
 
 func (a *Album) GetCurrentView() *ViewData {
@@ -27,14 +55,223 @@ func (a *Album) GetConfigurationFile(configFolder string) string {
 	return path.Join(configFolder, a.MD5+".yml")
 }
 
-func (a *Album) Reset() {
-	for i := 0; i < len(a.Views); i++ {
-		a.Views[i].Reset()
-		a.Views[i].BackgroundColors = nil
+// Reset rebuilds the pages of the album from the images of the archive, showing the hidden images and joining the
+// split spreads again, and resets all the settings but the bookmarks
+func (a *Album) Reset(entries []string) {
+	a.buildViews(entries)
+	a.GrayScale = false
+	a.RemoveBorders = false
+	a.RightToLeft = false
+	a.Adjustments = Adjustments{}
+	a.AutoLevels = false
+	a.PaperWhitening = false
+	a.AutoDeskew = false
+	a.Theme = filters.ThemeNone
+	a.Crop = nil
+	a.ConsistentCrop = nil
+	a.CurrentViewIndex = 0
+}
+
+// buildViews creates an image for each entry of the archive, and a view for each image
+func (a *Album) buildViews(entries []string) {
+	a.Images = make([]*ImageData, 0, len(entries))
+	a.Views = make([]*ViewData, 0, len(entries))
+	for _, fileName := range entries {
+		img := &ImageData{FileName: fileName, Visible: true}
+		a.Images = append(a.Images, img)
+		a.Views = append(a.Views, &ViewData{Images: []*ImageData{img}})
 	}
-	for _, i := range a.Images {
-		i.Visible = true
-		i.Rotation = None
+}
+
+// key identifies an image of the album : an entry of the archive, or a half of a split spread
+func (i *ImageData) key() string {
+	if i.Half == HalfNone {
+		return i.FileName
 	}
-	a.CurrentViewIndex = 0
+	return i.FileName + "#" + string(i.Half)
+}
+
+// linkImages makes the views and the list of images of a loaded album share the same images, in the order of the
+// entries of the archive : the images of the views are the ones edited, the images missing from the views are hidden
+func (a *Album) linkImages(entries []string) {
+	inViews := make(map[string]*ImageData)
+	for _, view := range a.Views {
+		for _, img := range view.Images {
+			inViews[img.key()] = img
+		}
+	}
+	byEntry := make(map[string][]*ImageData)
+	added := make(map[string]bool)
+	add := func(img *ImageData) {
+		if added[img.key()] {
+			return
+		}
+		added[img.key()] = true
+		if linked, found := inViews[img.key()]; found {
+			img = linked
+		}
+		img.Visible = inViews[img.key()] != nil
+		byEntry[img.FileName] = append(byEntry[img.FileName], img)
+	}
+	for _, img := range a.Images {
+		add(img)
+	}
+	// albums saved before the list of images was kept
+	for _, view := range a.Views {
+		for _, img := range view.Images {
+			add(img)
+		}
+	}
+
+	a.Images = make([]*ImageData, 0, len(entries))
+	for _, fileName := range entries {
+		images := byEntry[fileName]
+		if len(images) == 0 {
+			// an entry missing from the album was deleted before the images were hidden
+			images = []*ImageData{{FileName: fileName}}
+		}
+		a.Images = append(a.Images, images...)
+	}
+}
+
+// hiddenImages returns the images which are not displayed, in reading order
+func (a *Album) hiddenImages() []*ImageData {
+	var result []*ImageData
+	for _, img := range a.Images {
+		if !img.Visible {
+			result = append(result, img)
+		}
+	}
+	return result
+}
+
+// restoreImage displays a hidden image again, on a new view placed after the view of the previous visible image,
+// and returns the index of the new view
+func (a *Album) restoreImage(imgData *ImageData) int {
+	imgData.Visible = true
+	viewIndex := 0
+	for _, img := range a.Images {
+		if img == imgData {
+			break
+		}
+		if img.Visible {
+			if index := a.viewOfImage(img); index >= 0 {
+				viewIndex = index + 1
+			}
+		}
+	}
+	a.Views = append(a.Views[:viewIndex], append([]*ViewData{{Images: []*ImageData{imgData}}}, a.Views[viewIndex:]...)...)
+	if viewIndex <= a.CurrentViewIndex && len(a.Views) > 1 {
+		a.CurrentViewIndex++
+	}
+	return viewIndex
+}
+
+// viewOfImage returns the index of the view displaying an image, or -1
+func (a *Album) viewOfImage(imgData *ImageData) int {
+	for index, view := range a.Views {
+		for _, img := range view.Images {
+			if sameImage(img, imgData) {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// forEachImage calls fn for the images of the album and the images of its views, which can be distinct copies
+func (a *Album) forEachImage(fn func(img *ImageData)) {
+	for _, img := range a.Images {
+		fn(img)
+	}
+	for _, view := range a.Views {
+		for _, img := range view.Images {
+			fn(img)
+		}
+	}
+}
+
+// hideImage marks an image as hidden and removes it from the views, the views left empty being removed
+func (a *Album) hideImage(imgData *ImageData) {
+	if len(a.Views) == 1 && len(a.Views[0].Images) == 1 && sameImage(a.Views[0].Images[0], imgData) {
+		// the album keeps at least one page
+		return
+	}
+	a.forEachImage(func(img *ImageData) {
+		if sameImage(img, imgData) {
+			img.Visible = false
+		}
+	})
+	for viewIndex := 0; viewIndex < len(a.Views); viewIndex++ {
+		view := a.Views[viewIndex]
+		for imageIndex := 0; imageIndex < len(view.Images); imageIndex++ {
+			if sameImage(view.Images[imageIndex], imgData) {
+				view.Images = append(view.Images[:imageIndex], view.Images[imageIndex+1:]...)
+				imageIndex--
+			}
+		}
+		if len(view.Images) == 0 && len(a.Views) > 1 {
+			a.Views = append(a.Views[:viewIndex], a.Views[viewIndex+1:]...)
+			if viewIndex < a.CurrentViewIndex || a.CurrentViewIndex >= len(a.Views) {
+				a.CurrentViewIndex--
+			}
+			viewIndex--
+		}
+	}
+}
+
+// CropOptions returns the border removal options of the album, or the default options
+func (a *Album) CropOptions(defaults crop.Options) crop.Options {
+	if a.Crop != nil {
+		return *a.Crop
+	}
+	return defaults
+}
+
+// ChapterStarts returns the indexes of the views starting a new chapter, chapters
+// being the folders in which the images are stored in the archive.
+func (a *Album) ChapterStarts() []int {
+	var starts []int
+	previousFolder := ""
+	for index, view := range a.Views {
+		folder := path.Dir(view.Images[0].FileName)
+		if index > 0 && folder != previousFolder {
+			starts = append(starts, index)
+		}
+		previousFolder = folder
+	}
+	return starts
+}
+
+// ViewIndex returns the index of the view displaying the given image, or -1 if the image is not displayed
+func (a *Album) ViewIndex(fileName string) int {
+	for index, view := range a.Views {
+		for _, img := range view.Images {
+			if img.FileName == fileName {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// BookmarkIndex returns the index of the bookmark for the given view, or -1 if the view is not bookmarked
+func (a *Album) BookmarkIndex(viewIndex int) int {
+	for index, bookmark := range a.Bookmarks {
+		if a.ViewIndex(bookmark.FileName) == viewIndex {
+			return index
+		}
+	}
+	return -1
+}
+
+func (a *Album) AddBookmark(name string, viewIndex int) {
+	a.Bookmarks = append(a.Bookmarks, Bookmark{Name: name, FileName: a.Views[viewIndex].Images[0].FileName})
+	sort.SliceStable(a.Bookmarks, func(i, j int) bool {
+		return a.ViewIndex(a.Bookmarks[i].FileName) < a.ViewIndex(a.Bookmarks[j].FileName)
+	})
+}
+
+func (a *Album) RemoveBookmark(index int) {
+	a.Bookmarks = append(a.Bookmarks[:index], a.Bookmarks[index+1:]...)
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newAlbum returns an album with a page for each entry
+func newAlbum(entries ...string) *Album {
+	album := &Album{}
+	album.buildViews(entries)
+	return album
+}
+
+// pages describes the views of an album, the images of a view being separated by spaces
+func pages(a *Album) []string {
+	var result []string
+	for _, view := range a.Views {
+		var keys []string
+		for _, img := range view.Images {
+			keys = append(keys, img.key())
+		}
+		result = append(result, strings.Join(keys, " "))
+	}
+	return result
+}
+
+func TestHideAndRestoreImages(t *testing.T) {
+	album := newAlbum("a", "b", "c", "d")
+	b, c := album.Images[1], album.Images[2]
+	album.CurrentViewIndex = 2
+
+	album.hideImage(b)
+	if got, want := pages(album), []string{"a", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pages after hiding b : %q, want %q", got, want)
+	}
+	if b.Visible || album.CurrentViewIndex != 1 {
+		t.Errorf("b visible %v, current view %d, want hidden and current view 1 (still c)", b.Visible, album.CurrentViewIndex)
+	}
+
+	album.hideImage(c)
+	if got, want := pages(album), []string{"a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pages after hiding c : %q, want %q", got, want)
+	}
+	if hidden := album.hiddenImages(); len(hidden) != 2 || hidden[0] != b || hidden[1] != c {
+		t.Errorf("hidden images %v, want b and c in reading order", hidden)
+	}
+
+	// the images come back at their place in the archive, whatever the order they are restored in
+	if index := album.restoreImage(c); index != 1 {
+		t.Errorf("c restored on view %d, want 1", index)
+	}
+	if index := album.restoreImage(b); index != 1 {
+		t.Errorf("b restored on view %d, want 1", index)
+	}
+	if got, want := pages(album), []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages after restoring : %q, want %q", got, want)
+	}
+	if album.CurrentViewIndex != 3 || album.GetCurrentView().Images[0].FileName != "d" {
+		t.Errorf("current view %d, want 3 (still d)", album.CurrentViewIndex)
+	}
+	if len(album.hiddenImages()) != 0 {
+		t.Errorf("hidden images %v after restoring them all", album.hiddenImages())
+	}
+}
+
+func TestHideImageKeepsOnePage(t *testing.T) {
+	album := newAlbum("a")
+	album.hideImage(album.Images[0])
+	if got := pages(album); len(got) != 1 || !album.Images[0].Visible {
+		t.Errorf("pages %q, the last page of an album must not be hidden", got)
+	}
+}
+
+func TestHideHalfOfSpread(t *testing.T) {
+	album := newAlbum("a", "b")
+	left := &ImageData{FileName: "b", Half: HalfLeft, Visible: true}
+	right := &ImageData{FileName: "b", Half: HalfRight, Visible: true}
+	album.Images = []*ImageData{album.Images[0], left, right}
+	album.Views[1].Images = []*ImageData{left, right}
+
+	// a copy of the image, as selected from the thumbnails, hides the image of the view
+	album.hideImage(&ImageData{FileName: "b", Half: HalfRight})
+	if got, want := pages(album), []string{"a", "b#left"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages %q, want %q", got, want)
+	}
+	if right.Visible || !left.Visible {
+		t.Errorf("left visible %v, right visible %v, want only the right half hidden", left.Visible, right.Visible)
+	}
+}
+
+func TestLinkImages(t *testing.T) {
+	// an album read from its configuration file : the images of the views and the list of images are distinct copies
+	album := &Album{
+		Images: []*ImageData{
+			{FileName: "a", Visible: true},
+			{FileName: "b"},
+			{FileName: "c", Visible: true},
+		},
+		Views: []*ViewData{
+			{Images: []*ImageData{{FileName: "a", Rotation: Left}}},
+			{Images: []*ImageData{{FileName: "c"}}},
+		},
+	}
+	album.linkImages([]string{"a", "b", "c", "d"})
+
+	var keys []string
+	for _, img := range album.Images {
+		keys = append(keys, img.key())
+	}
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("images %q, want %q", keys, want)
+	}
+	if album.Images[0] != album.Views[0].Images[0] || album.Images[2] != album.Views[1].Images[0] {
+		t.Error("the images of the views are not shared with the list of images")
+	}
+	if album.Images[0].Rotation != Left {
+		t.Errorf("rotation %v, want the rotation edited in the view", album.Images[0].Rotation)
+	}
+	for i, visible := range []bool{true, false, true, false} {
+		if album.Images[i].Visible != visible {
+			t.Errorf("%s visible %v, want %v", album.Images[i].key(), album.Images[i].Visible, visible)
+		}
+	}
+}
+
+func TestLinkImagesWithoutList(t *testing.T) {
+	// albums saved before the list of images was kept only have views
+	album := &Album{
+		Views: []*ViewData{
+			{Images: []*ImageData{{FileName: "a"}}},
+			{Images: []*ImageData{{FileName: "b", Half: HalfLeft}, {FileName: "b", Half: HalfRight}}},
+		},
+	}
+	album.linkImages([]string{"a", "b"})
+
+	if len(album.Images) != 3 {
+		t.Fatalf("%d images, want 3", len(album.Images))
+	}
+	for i, img := range album.Images {
+		if !img.Visible {
+			t.Errorf("%s hidden", img.key())
+		}
+		if want := []string{"a", "b#left", "b#right"}[i]; img.key() != want {
+			t.Errorf("image %d is %s, want %s", i, img.key(), want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	album := newAlbum("a", "b")
+	album.hideImage(album.Images[0])
+	album.GrayScale, album.RemoveBorders, album.RightToLeft, album.AutoLevels = true, true, true, true
+	album.Bookmarks = []Bookmark{{Name: "end", FileName: "b"}}
+
+	album.Reset([]string{"a", "b"})
+	if got, want := pages(album), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages %q, want %q", got, want)
+	}
+	if album.GrayScale || album.RemoveBorders || album.RightToLeft || album.AutoLevels {
+		t.Error("settings not reset")
+	}
+	if len(album.Bookmarks) != 1 {
+		t.Error("bookmarks not kept")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/mozvip/gomics/crop"
+)
+
+const (
+	// maximum number of pages read for each side (odd and even) by the crop analysis
+	maxCropSamples = 24
+	// pages whose margins differ more than this from the median margins are ignored by the crop analysis
+	cropOutlierTolerance = 0.04
+	// pages whose aspect ratio differs more than this from the median aspect ratio are spreads or inserts
+	aspectOutlierTolerance = 0.25
+)
+
+// Margins are the parts of a page removed on each side, relative to the width or the height of the page
+type Margins struct {
+	Top    float64
+	Bottom float64
+	Left   float64
+	Right  float64
+}
+
+// Rect returns the part of bounds kept once the margins are removed
+func (m Margins) Rect(bounds image.Rectangle) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	return image.Rect(
+		bounds.Min.X+int(m.Left*w), bounds.Min.Y+int(m.Top*h),
+		bounds.Max.X-int(m.Right*w), bounds.Max.Y-int(m.Bottom*h),
+	)
+}
+
+// AlbumCrop is the crop applied to all the pages of an album, so that they all have the same size
+type AlbumCrop struct {
+	// Odd and Even are the margins of the odd and even pages, the cover being page 1 and the hidden images
+	// not being counted
+	Odd  Margins
+	Even Margins
+	// Aspect is the usual ratio between the width and the height of the pages, pages of
+	// another shape (spreads, inserts) are not cropped
+	Aspect float64
+	// Excluded are the keys of the images which are not cropped : the cover and the other pages found to be different
+	Excluded []string `yaml:",omitempty"`
+}
+
+// Margins returns the margins of an image of the album, pageIndex being its index in the visible images. ok is
+// false for the excluded images.
+func (c *AlbumCrop) Margins(pageIndex int, key string, bounds image.Rectangle) (Margins, bool) {
+	for _, excluded := range c.Excluded {
+		if excluded == key {
+			return Margins{}, false
+		}
+	}
+	if c.Aspect > 0 && math.Abs(float64(bounds.Dx())/float64(bounds.Dy())-c.Aspect) > c.Aspect*aspectOutlierTolerance {
+		return Margins{}, false
+	}
+	if pageIndex%2 == 0 {
+		return c.Odd, true
+	}
+	return c.Even, true
+}
+
+// baseCrop returns the part of an image kept by the consistent crop of the album, before the crop of the image
+// itself is applied, consistent is false when the image is not cropped by the album
+func baseCrop(imgData *ImageData, bounds image.Rectangle) (rect image.Rectangle, consistent bool) {
+	if album.ConsistentCrop != nil {
+		if margins, ok := album.ConsistentCrop.Margins(album.PageIndex(imgData), imgData.key(), bounds); ok {
+			return margins.Rect(bounds), true
+		}
+	}
+	return bounds, false
+}
+
+// ImageIndex returns the index of an image in the album, or -1
+func (a *Album) ImageIndex(imgData *ImageData) int {
+	for index, img := range a.Images {
+		if sameImage(img, imgData) {
+			return index
+		}
+	}
+	return -1
+}
+
+// PageIndex returns the index of an image in the visible images of the album, or -1 for a hidden image
+func (a *Album) PageIndex(imgData *ImageData) int {
+	index := 0
+	for _, img := range a.Images {
+		if sameImage(img, imgData) {
+			if !img.Visible {
+				return -1
+			}
+			return index
+		}
+		if img.Visible {
+			index++
+		}
+	}
+	return -1
+}
+
+// visibleImages returns a copy of the visible images of the album, in reading order
+func (a *Album) visibleImages() []ImageData {
+	var result []ImageData
+	for _, img := range a.Images {
+		if img.Visible {
+			result = append(result, *img)
+		}
+	}
+	return result
+}
+
+// pageMargins are the margins found on a page by the border removal
+type pageMargins struct {
+	key     string
+	aspect  float64
+	margins Margins
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// analyzeAlbumCrop reads a sample of the visible images of the album to compute margins fitting all of them
+func analyzeAlbumCrop(images []ImageData, options crop.Options) (*AlbumCrop, error) {
+	result := &AlbumCrop{}
+	if len(images) > 0 {
+		// the cover
+		result.Excluded = append(result.Excluded, images[0].key())
+	}
+
+	var sides [2][]pageMargins
+	var aspects []float64
+	for parity := 0; parity < 2; parity++ {
+		var candidates []*ImageData
+		for index := 1; index < len(images); index++ {
+			if index%2 == parity {
+				candidates = append(candidates, &images[index])
+			}
+		}
+		step := math.Max(1, float64(len(candidates))/maxCropSamples)
+		for i := 0.0; int(i) < len(candidates); i += step {
+			imgData := candidates[int(i)]
+			img, err := peekImage(imgData)
+			if err != nil {
+				return nil, err
+			}
+			bounds := img.Bounds()
+			rect := bounds
+			crop.CropImageBorders(img, &rect, options)
+			w, h := float64(bounds.Dx()), float64(bounds.Dy())
+			page := pageMargins{key: imgData.key(), aspect: w / h, margins: Margins{
+				Top:    float64(rect.Min.Y-bounds.Min.Y) / h,
+				Bottom: float64(bounds.Max.Y-rect.Max.Y) / h,
+				Left:   float64(rect.Min.X-bounds.Min.X) / w,
+				Right:  float64(bounds.Max.X-rect.Max.X) / w,
+			}}
+			sides[parity] = append(sides[parity], page)
+			aspects = append(aspects, page.aspect)
+		}
+	}
+
+	// spreads and inserts have a different shape than the other pages
+	medianAspect := median(aspects)
+	result.Aspect = medianAspect
+	for parity, pages := range sides {
+		var inliers []pageMargins
+		for _, page := range pages {
+			if math.Abs(page.aspect-medianAspect) > medianAspect*aspectOutlierTolerance {
+				result.Excluded = append(result.Excluded, page.key)
+			} else {
+				inliers = append(inliers, page)
+			}
+		}
+		margins := consistentMargins(inliers)
+		if parity == 0 {
+			// indexes 0, 2, 4... are the pages 1, 3, 5...
+			result.Odd = margins
+		} else {
+			result.Even = margins
+		}
+	}
+	return result, nil
+}
+
+// consistentMargins returns the smallest margins of the pages, ignoring the pages far from the median margins
+// like full bleed pages or pages with a large blank area
+func consistentMargins(pages []pageMargins) Margins {
+	if len(pages) == 0 {
+		return Margins{}
+	}
+	side := func(value func(m Margins) float64) float64 {
+		var values []float64
+		for _, page := range pages {
+			values = append(values, value(page.margins))
+		}
+		center := median(values)
+		result := center
+		for _, v := range values {
+			if math.Abs(v-center) <= cropOutlierTolerance {
+				result = math.Min(result, v)
+			}
+		}
+		return result
+	}
+	return Margins{
+		Top:    side(func(m Margins) float64 { return m.Top }),
+		Bottom: side(func(m Margins) float64 { return m.Bottom }),
+		Left:   side(func(m Margins) float64 { return m.Left }),
+		Right:  side(func(m Margins) float64 { return m.Right }),
+	}
+}
+
+// toggleAlbumCrop removes the consistent crop of the album, or analyzes the pages in the background to compute it
+func (g *GogoReader) toggleAlbumCrop() {
+	if album.ConsistentCrop != nil {
+		album.ConsistentCrop = nil
+		g.notify("Consistent crop removed")
+		g.needsRefresh = true
+		return
+	}
+	if g.albumCropRunning {
+		return
+	}
+	g.albumCropRunning = true
+	if g.albumCropResult == nil {
+		g.albumCropResult = make(chan *AlbumCrop, 1)
+	}
+	g.notify("Analyzing the pages of the album...")
+	// the goroutine reads copies, the images of the album being edited meanwhile
+	images := album.visibleImages()
+	options := album.CropOptions(g.preferences.Crop)
+	go func() {
+		result, err := analyzeAlbumCrop(images, options)
+		if err != nil {
+			g.notifyError(err)
+			result = nil
+		}
+		g.albumCropResult <- result
+	}()
+}
+
+// updateAlbumCrop applies the result of the crop analysis once it is available
+func (g *GogoReader) updateAlbumCrop() {
+	select {
+	case result := <-g.albumCropResult:
+		g.albumCropRunning = false
+		if result != nil {
+			g.record("consistent-crop", false, func() { album.ConsistentCrop = result })
+			g.notify("Consistent crop applied, %d page(s) excluded", len(result.Excluded))
+			g.needsRefresh = true
+		}
+	default:
+	}
+}
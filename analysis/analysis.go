@@ -0,0 +1,134 @@
+// Package analysis finds the pages of a comic which are probably not part of it : blank pages and duplicated pages
+package analysis
+
+import (
+	"image"
+	"math"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// Options controls the detection of the blank and duplicated pages
+type Options struct {
+	// BlankDeviation is the standard deviation of the luminance (0.0..1.0) below which a page is blank
+	BlankDeviation float64
+	// BlankEntropy is the entropy of the luminance histogram, in bits, below which a page is blank
+	BlankEntropy float64
+	// Margin is the ratio of the width and height ignored on each side, where the scans have dark edges
+	Margin float64
+	// DuplicateDistance is the maximum number of bits differing between the hashes of duplicated pages
+	DuplicateDistance int
+}
+
+// DefaultOptions are the options used by the reader
+var DefaultOptions = Options{
+	BlankDeviation:    0.04,
+	BlankEntropy:      0.1,
+	Margin:            0.05,
+	DuplicateDistance: 4,
+}
+
+// Fingerprint summarizes the content of a page
+type Fingerprint struct {
+	// Hash is a perceptual hash of the page : similar pages have hashes differing by a few bits
+	Hash uint64
+	// Deviation is the standard deviation of the luminance of the page
+	Deviation float64
+	// Entropy is the entropy of the luminance histogram of the page, in bits
+	Entropy float64
+}
+
+// Measure computes the fingerprint of a page
+func Measure(img image.Image, options Options) Fingerprint {
+	bounds := img.Bounds()
+	marginX, marginY := int(float64(bounds.Dx())*options.Margin), int(float64(bounds.Dy())*options.Margin)
+	inner := image.Rect(bounds.Min.X+marginX, bounds.Min.Y+marginY, bounds.Max.X-marginX, bounds.Max.Y-marginY)
+	if inner.Empty() {
+		inner = bounds
+	}
+	gray := imaging.Grayscale(imaging.Resize(imaging.Crop(img, inner), 256, 0, imaging.Box))
+
+	var fingerprint Fingerprint
+	var histogram [32]float64
+	var sum, squares, count float64
+	for i := 0; i < len(gray.Pix); i += 4 {
+		v := float64(gray.Pix[i]) / 255
+		sum += v
+		squares += v * v
+		count++
+		histogram[gray.Pix[i]/8]++
+	}
+	if count > 0 {
+		mean := sum / count
+		fingerprint.Deviation = math.Sqrt(math.Max(0, squares/count-mean*mean))
+		for _, n := range histogram {
+			if n > 0 {
+				p := n / count
+				fingerprint.Entropy -= p * math.Log2(p)
+			}
+		}
+	}
+
+	// difference hash : each bit tells whether a pixel of a 9x8 reduction is brighter than its right neighbour
+	small := imaging.Grayscale(imaging.Resize(img, 9, 8, imaging.Box))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			fingerprint.Hash <<= 1
+			if small.Pix[small.PixOffset(x, y)] > small.Pix[small.PixOffset(x+1, y)] {
+				fingerprint.Hash |= 1
+			}
+		}
+	}
+	return fingerprint
+}
+
+// Blank returns true when the page has almost no content
+func (f Fingerprint) Blank(options Options) bool {
+	return f.Deviation < options.BlankDeviation || f.Entropy < options.BlankEntropy
+}
+
+// Duplicates returns true when two pages look the same
+func (f Fingerprint) Duplicates(other Fingerprint, options Options) bool {
+	return bits.OnesCount64(f.Hash^other.Hash) <= options.DuplicateDistance
+}
+
+// Finding is a page found to be blank or a duplicate of a previous page
+type Finding struct {
+	Index int
+	Blank bool
+	// DuplicateOf is the index of the page duplicated, -1 for blank pages
+	DuplicateOf int
+}
+
+// Analyze reads count pages and returns those which are blank or duplicates of a previous page, in page order
+func Analyze(count int, read func(index int) (image.Image, error), options Options) ([]Finding, error) {
+	var findings []Finding
+	fingerprints := make([]Fingerprint, 0, count)
+	// blank pages all look the same, they are not compared
+	var contentPages []int
+	for index := 0; index < count; index++ {
+		img, err := read(index)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint := Measure(img, options)
+		fingerprints = append(fingerprints, fingerprint)
+		if fingerprint.Blank(options) {
+			findings = append(findings, Finding{Index: index, Blank: true, DuplicateOf: -1})
+			continue
+		}
+		duplicate := false
+		for _, previous := range contentPages {
+			if fingerprint.Duplicates(fingerprints[previous], options) {
+				findings = append(findings, Finding{Index: index, DuplicateOf: previous})
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			contentPages = append(contentPages, index)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,114 @@
+package analysis
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// drawing returns a page with large shapes placed according to seed, pages with different seeds do not look alike
+func drawing(seed int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 300, 400))
+	fx, fy := float64(seed%3+1)*math.Pi/300, float64(seed%4+1)*math.Pi/400
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 300; x++ {
+			v := 128 + 100*math.Sin(float64(x)*fx+float64(seed))*math.Cos(float64(y)*fy+float64(2*seed))
+			img.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return img
+}
+
+// blank returns a page of paper, with the dark edges of a scan
+func blank() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 300, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 300; x++ {
+			v := uint8(245)
+			if x < 5 || y > 394 {
+				v = 10
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// scanned returns a copy of an image as scanned again : slightly brighter, with some noise
+func scanned(img image.Image) image.Image {
+	bounds := img.Bounds()
+	rescan := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			v := int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y) + 8 + (x*y)%5
+			if v > 255 {
+				v = 255
+			}
+			rescan.SetGray(x, y, color.Gray{Y: uint8(v)})
+		}
+	}
+	return rescan
+}
+
+func TestAnalyze(t *testing.T) {
+	first, second := drawing(1), drawing(2)
+	tests := []struct {
+		name  string
+		pages []image.Image
+		want  []Finding
+	}{
+		{
+			name:  "no finding",
+			pages: []image.Image{first, second, drawing(3)},
+		},
+		{
+			name:  "blank pages",
+			pages: []image.Image{blank(), first, blank(), second},
+			want:  []Finding{{Index: 0, Blank: true, DuplicateOf: -1}, {Index: 2, Blank: true, DuplicateOf: -1}},
+		},
+		{
+			name:  "duplicate",
+			pages: []image.Image{first, second, first},
+			want:  []Finding{{Index: 2, DuplicateOf: 0}},
+		},
+		{
+			name:  "duplicate scanned again",
+			pages: []image.Image{first, second, scanned(second)},
+			want:  []Finding{{Index: 2, DuplicateOf: 1}},
+		},
+		{
+			name:  "duplicates of the first occurrence",
+			pages: []image.Image{second, first, second, blank(), second},
+			want:  []Finding{{Index: 2, DuplicateOf: 0}, {Index: 3, Blank: true, DuplicateOf: -1}, {Index: 4, DuplicateOf: 0}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			findings, err := Analyze(len(test.pages), func(index int) (image.Image, error) {
+				return test.pages[index], nil
+			}, DefaultOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(findings, test.want) {
+				t.Errorf("Analyze() = %v, want %v", findings, test.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeError(t *testing.T) {
+	readErr := errors.New("unreadable page")
+	_, err := Analyze(3, func(index int) (image.Image, error) {
+		if index == 1 {
+			return nil, readErr
+		}
+		return drawing(index), nil
+	}, DefaultOptions)
+	if err != readErr {
+		t.Errorf("Analyze() error = %v, want %v", err, readErr)
+	}
+}
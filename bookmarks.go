@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+// toggleBookmark removes the bookmark of the current view, or asks for a name to bookmark it
+func (g *GogoReader) toggleBookmark() {
+	if index := album.BookmarkIndex(album.CurrentViewIndex); index >= 0 {
+		g.notify("Bookmark %s removed", album.Bookmarks[index].Name)
+		album.RemoveBookmark(index)
+		return
+	}
+	viewIndex := album.CurrentViewIndex
+	g.openDialog("Bookmark name :", nil, func(value string) {
+		if value == "" {
+			value = fmt.Sprintf("Page %d", viewIndex+1)
+		}
+		album.AddBookmark(value, viewIndex)
+		g.notify("Bookmark %s added", value)
+	})
+	g.dialog.Value = fmt.Sprintf("Page %d", viewIndex+1)
+}
+
+func (g *GogoReader) toggleBookmarksDisplay() {
+	g.bookmarksDisplay = !g.bookmarksDisplay
+	g.bookmarkSelection = 0
+	for index := range album.Bookmarks {
+		if album.ViewIndex(album.Bookmarks[index].FileName) <= album.CurrentViewIndex {
+			g.bookmarkSelection = index
+		}
+	}
+}
+
+func (g *GogoReader) updateBookmarks() {
+	if g.win.JustPressed(pixelgl.KeyEscape) || g.gamepadJustPressed(pixelgl.ButtonB) || g.actionTriggered("bookmarks") || len(album.Bookmarks) == 0 {
+		g.bookmarksDisplay = false
+		return
+	}
+
+	if (g.win.JustPressed(pixelgl.KeyDown) || g.win.Repeated(pixelgl.KeyDown) || g.gamepadJustPressed(pixelgl.ButtonDpadDown) || g.win.MouseScroll().Y < 0) && g.bookmarkSelection < len(album.Bookmarks)-1 {
+		g.bookmarkSelection++
+	}
+	if (g.win.JustPressed(pixelgl.KeyUp) || g.win.Repeated(pixelgl.KeyUp) || g.gamepadJustPressed(pixelgl.ButtonDpadUp) || g.win.MouseScroll().Y > 0) && g.bookmarkSelection > 0 {
+		g.bookmarkSelection--
+	}
+	if g.win.JustPressed(pixelgl.KeyDelete) {
+		album.RemoveBookmark(g.bookmarkSelection)
+		g.bookmarkSelection = int(math.Max(0, math.Min(float64(g.bookmarkSelection), float64(len(album.Bookmarks)-1))))
+		return
+	}
+
+	jump := g.win.JustPressed(pixelgl.KeyEnter) || g.win.JustPressed(pixelgl.KeyKPEnter) || g.gamepadJustPressed(pixelgl.ButtonA)
+	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
+		for index := range album.Bookmarks {
+			if g.bookmarkLine(index).Contains(g.win.MousePosition()) {
+				g.bookmarkSelection = index
+				jump = true
+			}
+		}
+	}
+	if jump {
+		viewIndex := album.ViewIndex(album.Bookmarks[g.bookmarkSelection].FileName)
+		if viewIndex >= 0 {
+			g.goTo(viewIndex)
+			g.bookmarksDisplay = false
+		}
+	}
+}
+
+const bookmarksTextScale = 2.0
+
+// bookmarkLine returns the rectangle of the line used to display a bookmark in the list
+func (g *GogoReader) bookmarkLine(index int) pixel.Rect {
+	lineHeight := fontAtlas.LineHeight() * bookmarksTextScale
+	top := g.size.Y/2 + float64(len(album.Bookmarks))*lineHeight/2
+	return pixel.R(g.size.X/4, top-float64(index+1)*lineHeight, g.size.X*3/4, top-float64(index)*lineHeight)
+}
+
+func (g *GogoReader) drawBookmarks() {
+	if !g.bookmarksDisplay {
+		return
+	}
+
+	imd := imdraw.New(nil)
+	first, last := g.bookmarkLine(0), g.bookmarkLine(len(album.Bookmarks)-1)
+	imd.Color = color.RGBA{30, 30, 30, 220}
+	imd.Push(pixel.V(first.Min.X-10, last.Min.Y-10), pixel.V(first.Max.X+10, first.Max.Y+10))
+	imd.Rectangle(0)
+	selection := g.bookmarkLine(g.bookmarkSelection)
+	imd.Color = color.RGBA{90, 140, 200, 220}
+	imd.Push(selection.Min, selection.Max)
+	imd.Rectangle(0)
+	imd.Draw(g.win)
+
+	for index, bookmark := range album.Bookmarks {
+		line := g.bookmarkLine(index)
+		page := "-"
+		if viewIndex := album.ViewIndex(bookmark.FileName); viewIndex >= 0 {
+			page = fmt.Sprintf("%d", viewIndex+1)
+		}
+		bookmarkText := text.New(pixel.ZV, fontAtlas)
+		fmt.Fprintf(bookmarkText, "%5s  %s", page, bookmark.Name)
+		bookmarkText.Draw(g.win, pixel.IM.Scaled(pixel.ZV, bookmarksTextScale).Moved(pixel.V(line.Min.X+5, line.Min.Y+fontAtlas.Descent()*bookmarksTextScale)))
+	}
+}
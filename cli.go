@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mozvip/gomics/analysis"
+	"github.com/mozvip/gomics/files"
+	"github.com/mozvip/gomics/thumbnails"
+	"gopkg.in/yaml.v3"
+)
+
+// commands that can be run headless instead of opening an album,
+// for example : gogoreader thumbnail -size large album.cbz
+var commands = map[string]func(args []string) error{
+	"thumbnail": thumbnailCommand,
+	"bookmarks": bookmarksCommand,
+	"keys":      keysCommand,
+	"analyze":   analyzeCommand,
+}
+
+// thumbnailCommand fills the thumbnail cache for the given archives and loose images
+func thumbnailCommand(args []string) error {
+	flags := flag.NewFlagSet("thumbnail", flag.ExitOnError)
+	sizeName := flags.String("size", thumbnails.Normal.Dir(), "thumbnail size : normal, large, x-large or xx-large")
+	cleanup := flags.Bool("cleanup", false, "remove the least recently used thumbnails when the cache is over its maximum size")
+	flags.Parse(args)
+
+	size, err := thumbnails.ParseSize(*sizeName)
+	if err != nil {
+		return err
+	}
+	preferences, err := readPreferences()
+	if err != nil {
+		return err
+	}
+	cache := newThumbnailCache(preferences)
+
+	for _, fileName := range flags.Args() {
+		if isImageEntry(fileName) {
+			err = thumbnailImage(cache, size, fileName)
+		} else {
+			err = thumbnailArchive(cache, size, fileName)
+		}
+		if err != nil {
+			return fmt.Errorf("%s : %w", fileName, err)
+		}
+	}
+
+	if *cleanup {
+		return cache.Cleanup()
+	}
+	return nil
+}
+
+func thumbnailImage(cache *thumbnails.Cache, size thumbnails.Size, fileName string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return err
+	}
+	uri, err := thumbnails.FileURI(fileName)
+	if err != nil {
+		return err
+	}
+	_, err = cache.GetOrCreate(uri, info.ModTime().Unix(), size, func() (image.Image, error) {
+		file, err := os.Open(fileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return files.DecodeImage(fileName, file)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(cache.Path(uri, size))
+	return nil
+}
+
+func thumbnailArchive(cache *thumbnails.Cache, size thumbnails.Size, fileName string) error {
+	archive, err := files.FromFile(fileName)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	err = archive.Init()
+	if err != nil {
+		return err
+	}
+
+	entries, err := listImages(archive)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		uri := thumbnails.EntryURI(archive.GetMD5(), entry)
+		// images are only read once, no need to keep them in memory
+		_, err = cache.GetOrCreate(uri, 0, size, func() (image.Image, error) {
+			return archive.ReadEntryUncached(entry)
+		})
+		if err != nil {
+			return fmt.Errorf("%s : %w", entry, err)
+		}
+		fmt.Printf("%s\t%s\n", cache.Path(uri, size), entry)
+	}
+	return nil
+}
+
+// bookmarksCommand lists the bookmarks of all the albums found in the configuration folder
+func bookmarksCommand(args []string) error {
+	flags := flag.NewFlagSet("bookmarks", flag.ExitOnError)
+	flags.Parse(args)
+
+	configurationFiles, err := filepath.Glob(path.Join(configFolder, "*.yml"))
+	if err != nil {
+		return err
+	}
+	for _, configurationFile := range configurationFiles {
+		if configurationFile == getGlobalConfigurationFile() || strings.HasSuffix(configurationFile, ".history.yml") {
+			// not the settings of an album
+			continue
+		}
+		fileData, err := ioutil.ReadFile(configurationFile)
+		if err != nil {
+			return err
+		}
+		var albumConfiguration Album
+		err = yaml.Unmarshal(fileData, &albumConfiguration)
+		if err != nil {
+			return fmt.Errorf("%s : %w", configurationFile, err)
+		}
+		if len(albumConfiguration.Bookmarks) == 0 {
+			continue
+		}
+
+		name := albumConfiguration.FileName
+		if name == "" {
+			name = albumConfiguration.MD5
+		}
+		fmt.Println(name)
+		for _, bookmark := range albumConfiguration.Bookmarks {
+			page := "-"
+			if viewIndex := albumConfiguration.ViewIndex(bookmark.FileName); viewIndex >= 0 {
+				page = fmt.Sprintf("%d", viewIndex+1)
+			}
+			fmt.Printf("\t%s\t%s\t%s\n", page, bookmark.Name, bookmark.FileName)
+		}
+	}
+	return nil
+}
+
+// keysCommand lists the actions and their bindings, taking config.yml into account
+func keysCommand(args []string) error {
+	flags := flag.NewFlagSet("keys", flag.ExitOnError)
+	flags.Parse(args)
+
+	preferences, err := readPreferences()
+	if err != nil {
+		return err
+	}
+	keyMap := NewKeyMap(actions, preferences.KeyBindings)
+	for _, warning := range keyMap.Warnings {
+		fmt.Fprintln(os.Stderr, "Warning :", warning)
+	}
+	for _, line := range keyMap.Help(actions) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// analyzeCommand lists the blank and duplicated pages of the given archives
+func analyzeCommand(args []string) error {
+	flags := flag.NewFlagSet("analyze", flag.ExitOnError)
+	options := analysis.DefaultOptions
+	flags.Float64Var(&options.BlankDeviation, "blank-deviation", options.BlankDeviation, "standard deviation of the luminance below which a page is blank")
+	flags.IntVar(&options.DuplicateDistance, "duplicate-distance", options.DuplicateDistance, "maximum number of bits differing between the hashes of duplicated pages")
+	flags.Parse(args)
+
+	for _, fileName := range flags.Args() {
+		if err := analyzeArchive(fileName, options); err != nil {
+			return fmt.Errorf("%s : %w", fileName, err)
+		}
+	}
+	return nil
+}
+
+func analyzeArchive(fileName string, options analysis.Options) error {
+	archive, err := files.FromFile(fileName)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	err = archive.Init()
+	if err != nil {
+		return err
+	}
+
+	entries, err := listImages(archive)
+	if err != nil {
+		return err
+	}
+	// images are only read once, no need to keep them in memory
+	findings, err := analysis.Analyze(len(entries), func(index int) (image.Image, error) {
+		return archive.ReadEntryUncached(entries[index])
+	}, options)
+	if err != nil {
+		return err
+	}
+	fmt.Println(fileName)
+	for _, finding := range findings {
+		if finding.Blank {
+			fmt.Printf("\t%d\t%s\t%s\n", finding.Index+1, FlagBlank, entries[finding.Index])
+		} else {
+			fmt.Printf("\t%d\t%s of %d\t%s\n", finding.Index+1, FlagDuplicate, finding.DuplicateOf+1, entries[finding.Index])
+		}
+	}
+	return nil
+}
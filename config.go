@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"regexp"
 	"sort"
@@ -11,41 +12,46 @@ import (
 	"strings"
 
 	"github.com/faiface/pixel"
+	"github.com/mozvip/gomics/files"
 	"gopkg.in/yaml.v3"
 )
 
-func buildDefaultConfig() error {
-	content, e := comicBook.List()
+// isImageEntry returns true if the archive entry is an image that can be displayed
+func isImageEntry(fileName string) bool {
+	if strings.HasPrefix(fileName, "__MACOSX") {
+		return false
+	}
+	ext := strings.ToLower(fileName)
+	return strings.HasPrefix(fileName, "PDF Page") || strings.HasSuffix(ext, ".jpg") || strings.HasSuffix(ext, ".jpeg") || strings.HasSuffix(ext, ".webp") || strings.HasSuffix(ext, ".png") || strings.HasSuffix(ext, ".gif")
+}
+
+// listImages returns the names of all the images of the archive, in reading order
+func listImages(archive files.ComicBookArchive) ([]string, error) {
+	content, e := archive.List()
 	if e != nil {
-		log.Fatal(e)
+		return nil, e
 	}
+	var images []string
 	for _, fileName := range content {
-		if strings.HasPrefix(fileName, "__MACOSX") {
-			continue
-		}
-		ext := strings.ToLower(fileName)
-		if strings.HasPrefix(fileName, "PDF Page") || strings.HasSuffix(ext, ".jpg") || strings.HasSuffix(ext, ".jpeg") || strings.HasSuffix(ext, ".webp") || strings.HasSuffix(ext, ".png") || strings.HasSuffix(ext, ".gif") {
-			album.Images = append(album.Images, &ImageData{
-				FileName: fileName,
-				Visible:  true,
-			})
+		if isImageEntry(fileName) {
+			images = append(images, fileName)
 		}
 	}
-	if len(album.Images) == 0 {
-		return errors.New("no image found in archive")
+	if len(images) == 0 {
+		return nil, errors.New("no image found in archive")
 	}
 
 	var r, err = regexp.Compile(`\d+`)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// sort images by their filename
-	sort.Slice(album.Images, func(i, j int) bool {
+	sort.Slice(images, func(i, j int) bool {
 		// extract number for file name
 
-		var imatch = strings.Join(r.FindAllString(album.Images[i].FileName, -1), "")
-		var jmatch = strings.Join(r.FindAllString(album.Images[j].FileName, -1), "")
+		var imatch = strings.Join(r.FindAllString(images[i], -1), "")
+		var jmatch = strings.Join(r.FindAllString(images[j], -1), "")
 		if imatch != "" && jmatch != "" {
 			var numsI, _ = strconv.Atoi(imatch)
 			var numsJ, _ = strconv.Atoi(jmatch)
@@ -54,21 +60,24 @@ func buildDefaultConfig() error {
 		return i < j
 	})
 
-	// create a default page for each of these images
-	album.Views = make([]*ViewData, len(album.Images))
-	for i, img := range album.Images {
-		album.Views[i] = &ViewData{Images: []*ImageData{img}}
-	}
+	return images, nil
+}
 
+func buildDefaultConfig() error {
+	images, err := listImages(comicBook)
+	if err != nil {
+		return err
+	}
+	// create a default page for each of these images
+	album.buildViews(images)
 	return nil
 }
 
-func readConfiguration(fileMD5 string) (Preferences, error) {
-	var err error
+func readPreferences() (Preferences, error) {
 	var preferences = NewPreferences()
 
 	globalConfigurationFile := getGlobalConfigurationFile()
-	_, err = os.Stat(globalConfigurationFile)
+	_, err := os.Stat(globalConfigurationFile)
 	if err == nil {
 		log.Printf("Loading global configuration from %s\n", globalConfigurationFile)
 		fileData, err := ioutil.ReadFile(globalConfigurationFile)
@@ -80,6 +89,8 @@ func readConfiguration(fileMD5 string) (Preferences, error) {
 			panic(err)
 		}
 	}
+	// a dead zone covering the whole range of the sticks would divide by zero
+	preferences.GamepadDeadzone = math.Max(0, math.Min(preferences.GamepadDeadzone, maxGamepadDeadzone))
 	if preferences.WindowedSize.X == 0 {
 		preferences.WindowedSize = pixel.Vec{
 			X: 800,
@@ -87,6 +98,15 @@ func readConfiguration(fileMD5 string) (Preferences, error) {
 		}
 	}
 
+	return preferences, nil
+}
+
+func readConfiguration(fileMD5 string) (Preferences, error) {
+	preferences, err := readPreferences()
+	if err != nil {
+		return preferences, err
+	}
+
 	album.Views = make([]*ViewData, 0)
 	album.MD5 = fileMD5
 
@@ -106,6 +126,17 @@ func readConfiguration(fileMD5 string) (Preferences, error) {
 		if err != nil {
 			panic(err)
 		}
+		images, err := listImages(comicBook)
+		if err != nil {
+			return preferences, err
+		}
+		album.linkImages(images)
+
+		if preferences.SaveHistory {
+			if err := readHistory(); err != nil {
+				return preferences, err
+			}
+		}
 	}
 
 	log.Printf("Album has %d pages\n", len(album.Views))
@@ -124,6 +155,15 @@ func saveConfiguration(preferences Preferences) error {
 	if err != nil {
 		return err
 	}
+	if preferences.SaveHistory {
+		err = saveHistory()
+	} else {
+		// an older history would be loaded again, out of step with the album, once the setting is turned on
+		err = removeHistory()
+	}
+	if err != nil {
+		return err
+	}
 
 	prefs, err := yaml.Marshal(&preferences)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 
 	"github.com/faiface/pixel"
+	"github.com/mozvip/gomics/sampling"
 )
 
 // comparator is a function that returns a difference between two colors in
@@ -14,11 +15,11 @@ type comparator func(r1, g1, b1, r2, g2, b2 float64) float64
 
 // CmpRGBComponents returns RGB components difference of two colors.
 func CmpRGBComponents(r1, g1, b1, r2, g2, b2 float64) float64 {
-	const maxDiff = 765.0 // Difference between black and white colors
+	const maxDiff = 3.0 // Difference between black and white colors, components being in range 0.0..1.0
 
-	return (max(r1, r2) - min(r1, r2)) +
+	return ((max(r1, r2) - min(r1, r2)) +
 		(max(g1, g2) - min(g1, g2)) +
-		(max(b1, b2)-min(b1, b2))/maxDiff
+		(max(b1, b2) - min(b1, b2))) / maxDiff
 }
 
 // min is minimum of two uint32
@@ -29,6 +30,13 @@ func min(a, b float64) float64 {
 	return b
 }
 
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 // max is maximum of two uint32
 func max(a, b float64) float64 {
 	if a > b {
@@ -37,16 +45,74 @@ func max(a, b float64) float64 {
 	return b
 }
 
+// Options controls the detection of the borders
+type Options struct {
+	// Threshold is the difference between two colors (0.0..1.0) above which a pixel is not part of the border
+	Threshold float64
+	// CountThreshold is the ratio of the pixels of a line which can differ from the border
+	CountThreshold float64
+	// Step is the distance in pixels between the pixels compared along a line
+	Step int
+	// MaxCrop is the maximum ratio of the width or height removed on each side, 0 for no limit
+	MaxCrop float64
+	// Symmetric removes the same amount on opposite sides, the smallest of the two borders
+	Symmetric bool
+	// Comparator is the name of the function comparing the colors : rgb, cie76 or ciede2000
+	Comparator string
+}
+
+// DefaultOptions are the options used by CropBorders
+var DefaultOptions = Options{
+	// CmpRGBComponents averages the three components, 0.05 keeps the sensitivity the sum of the red and green
+	// differences had with a threshold of 0.10
+	Threshold:      0.05,
+	CountThreshold: 0.01,
+	Step:           4,
+	Comparator:     ComparatorRGB,
+}
+
+const (
+	ComparatorRGB       = "rgb"
+	ComparatorCIE76     = "cie76"
+	ComparatorCIEDE2000 = "ciede2000"
+)
+
+// Comparators lists the names of the comparators
+var Comparators = []string{ComparatorRGB, ComparatorCIE76, ComparatorCIEDE2000}
+
+var comparators = map[string]comparator{
+	ComparatorRGB:       CmpRGBComponents,
+	ComparatorCIE76:     CmpCIE76,
+	ComparatorCIEDE2000: CmpCIEDE2000,
+}
+
+// comparator returns the comparator of the options, CmpRGBComponents when it is unknown
+func (o Options) comparator() comparator {
+	if c, found := comparators[o.Comparator]; found {
+		return c
+	}
+	return CmpRGBComponents
+}
+
 func CropBorders(img *pixel.PictureData, rect *image.Rectangle) {
-	CropBordersWithComparator(img, rect, CmpRGBComponents)
+	CropBordersWithOptions(img, rect, DefaultOptions)
+}
+
+// CropBordersWithOptions reduces rect to remove the borders of the picture, rect being in picture coordinates (from the bottom left corner)
+func CropBordersWithOptions(img *pixel.PictureData, rect *image.Rectangle, options Options) {
+	cropBorders(sampling.NewPictureReader(img), rect, options, options.comparator())
+}
+
+// CropImageBorders reduces rect to remove the borders of the image, rect being in image coordinates (from the top left corner)
+func CropImageBorders(img image.Image, rect *image.Rectangle, options Options) {
+	cropBorders(sampling.NewReader(img), rect, options, options.comparator())
 }
 
-func avgColorForLine(img *pixel.PictureData, y, minX, maxX int32) (r, g, b float64) {
+func avgColorForLine(read sampling.Reader, y, minX, maxX int32) (r, g, b float64) {
 	var sumR, sumG, sumB float64
 	var c float64
 	for x := minX; x < maxX; x++ {
-		color := img.Color(pixel.V(float64(x), float64(y)))
-		r, g, b := color.R, color.G, color.B
+		r, g, b := read(int(x), int(y))
 		sumR += r
 		sumG += g
 		sumB += b
@@ -58,12 +124,11 @@ func avgColorForLine(img *pixel.PictureData, y, minX, maxX int32) (r, g, b float
 	return sumR / c, sumG / c, sumB / c
 }
 
-func avgColorForColumn(img *pixel.PictureData, x, minY, maxY int32) (r, g, b float64) {
+func avgColorForColumn(read sampling.Reader, x, minY, maxY int32) (r, g, b float64) {
 	var sumR, sumG, sumB float64
 	var c float64
 	for y := minY; y < maxY; y++ {
-		color := img.Color(pixel.V(float64(x), float64(y)))
-		r, g, b := color.R, color.G, color.B
+		r, g, b := read(int(x), int(y))
 		sumR += r
 		sumG += g
 		sumB += b
@@ -76,8 +141,12 @@ func avgColorForColumn(img *pixel.PictureData, x, minY, maxY int32) (r, g, b flo
 }
 
 func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, comparator comparator) {
-	threshold := 0.10
-	countThreshold := 0.01
+	cropBorders(sampling.NewPictureReader(img), rect, DefaultOptions, comparator)
+}
+
+func cropBorders(read sampling.Reader, rect *image.Rectangle, options Options, comparator comparator) {
+	threshold := options.Threshold
+	countThreshold := options.CountThreshold
 
 	var wg sync.WaitGroup
 
@@ -86,19 +155,30 @@ func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, co
 	rectMinX := int32(rect.Min.X)
 	rectMaxX := int32(rect.Max.X)
 
-	const step = 4
+	step := int32(options.Step)
+	if step < 1 {
+		step = 1
+	}
+
+	// limits of the crop on each side
+	maxCropY, maxCropX := int32(rect.Dy()), int32(rect.Dx())
+	if options.MaxCrop > 0 {
+		maxCropY = int32(float64(rect.Dy()) * options.MaxCrop)
+		maxCropX = int32(float64(rect.Dx()) * options.MaxCrop)
+	}
+	limitMinY, limitMaxY := rectMinY+maxCropY, rectMaxY-maxCropY
+	limitMinX, limitMaxX := rectMinX+maxCropX, rectMaxX-maxCropX
 
 	maxBadForX := int(float64(rect.Dx()) * countThreshold)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		r, g, b := avgColorForLine(img, atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMaxX))
-		for minY := atomic.LoadInt32(&rectMinY); minY < atomic.LoadInt32(&rectMaxY); minY++ {
+		r, g, b := avgColorForLine(read, atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMaxX))
+		for minY := atomic.LoadInt32(&rectMinY); minY < atomic.LoadInt32(&rectMaxY) && minY < limitMinY; minY++ {
 			badCount := 0
 			for x := atomic.LoadInt32(&rectMinX); x < atomic.LoadInt32(&rectMaxX); x += step {
-				color := img.Color(pixel.V(float64(x), float64(minY)))
-				r1, g1, b1 := color.R, color.G, color.B
+				r1, g1, b1 := read(int(x), int(minY))
 				if comparator(r1, g1, b1, r, g, b) > threshold {
 					badCount++
 					if badCount > maxBadForX {
@@ -114,12 +194,11 @@ func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, co
 	go func() {
 		defer wg.Done()
 
-		r, g, b := avgColorForLine(img, atomic.LoadInt32(&rectMaxY)-1, atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMaxX)-1)
-		for maxY := atomic.LoadInt32(&rectMaxY) - 1; maxY > atomic.LoadInt32(&rectMinY); maxY-- {
+		r, g, b := avgColorForLine(read, atomic.LoadInt32(&rectMaxY)-1, atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMaxX)-1)
+		for maxY := atomic.LoadInt32(&rectMaxY) - 1; maxY > atomic.LoadInt32(&rectMinY) && maxY >= limitMaxY; maxY-- {
 			badCount := 0
 			for x := atomic.LoadInt32(&rectMinX); x < atomic.LoadInt32(&rectMaxX); x += step {
-				color := img.Color(pixel.V(float64(x), float64(maxY)))
-				r1, g1, b1 := color.R, color.G, color.B
+				r1, g1, b1 := read(int(x), int(maxY))
 				if comparator(r1, g1, b1, r, g, b) > threshold {
 					badCount++
 					if badCount > maxBadForX {
@@ -136,12 +215,11 @@ func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, co
 	go func() {
 		defer wg.Done()
 
-		r, g, b := avgColorForColumn(img, atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMaxY))
-		for minX := int(atomic.LoadInt32(&rectMinX)); minX < int(atomic.LoadInt32(&rectMaxX)); minX++ {
+		r, g, b := avgColorForColumn(read, atomic.LoadInt32(&rectMinX), atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMaxY))
+		for minX := atomic.LoadInt32(&rectMinX); minX < atomic.LoadInt32(&rectMaxX) && minX < limitMinX; minX++ {
 			badCount := 0
-			for y := rect.Min.Y; y < rect.Max.Y; y += step {
-				color := img.Color(pixel.V(float64(minX), float64(y)))
-				r1, g1, b1 := color.R, color.G, color.B
+			for y := int32(rect.Min.Y); y < int32(rect.Max.Y); y += step {
+				r1, g1, b1 := read(int(minX), int(y))
 
 				if comparator(r1, g1, b1, r, g, b) > threshold {
 					badCount++
@@ -158,12 +236,11 @@ func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, co
 	go func() {
 		defer wg.Done()
 
-		r, g, b := avgColorForColumn(img, atomic.LoadInt32(&rectMaxX)-1, atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMaxY)-1)
-		for maxX := atomic.LoadInt32(&rectMaxX) - 1; maxX > atomic.LoadInt32(&rectMinX); maxX-- {
+		r, g, b := avgColorForColumn(read, atomic.LoadInt32(&rectMaxX)-1, atomic.LoadInt32(&rectMinY), atomic.LoadInt32(&rectMaxY)-1)
+		for maxX := atomic.LoadInt32(&rectMaxX) - 1; maxX > atomic.LoadInt32(&rectMinX) && maxX >= limitMaxX; maxX-- {
 			badCount := 0
 			for y := atomic.LoadInt32(&rectMinY); y < atomic.LoadInt32(&rectMaxY); y += step {
-				color := img.Color(pixel.V(float64(maxX), float64(y)))
-				r1, g1, b1 := color.R, color.G, color.B
+				r1, g1, b1 := read(int(maxX), int(y))
 				if comparator(r1, g1, b1, r, g, b) > threshold {
 					badCount++
 					if badCount > maxBadForY {
@@ -177,6 +254,14 @@ func CropBordersWithComparator(img *pixel.PictureData, rect *image.Rectangle, co
 
 	wg.Wait()
 
+	if options.Symmetric {
+		top, bottom := rectMinY-int32(rect.Min.Y), int32(rect.Max.Y)-rectMaxY
+		left, right := rectMinX-int32(rect.Min.X), int32(rect.Max.X)-rectMaxX
+		vertical, horizontal := min32(top, bottom), min32(left, right)
+		rectMinY, rectMaxY = int32(rect.Min.Y)+vertical, int32(rect.Max.Y)-vertical
+		rectMinX, rectMaxX = int32(rect.Min.X)+horizontal, int32(rect.Max.X)-horizontal
+	}
+
 	rect.Min.X = int(rectMinX)
 	rect.Max.X = int(rectMaxX)
 	rect.Min.Y = int(rectMinY)
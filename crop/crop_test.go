@@ -0,0 +1,167 @@
+package crop
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/mozvip/gomics/sampling"
+)
+
+// opaque hides the type of an image, its pixels are then read through At
+type opaque struct {
+	image.Image
+}
+
+// page returns an image of the given type : a white page with a dark picture inside content
+func page(kind string, bounds, content image.Rectangle) image.Image {
+	colorAt := func(x, y int) color.Gray {
+		if (image.Point{X: x, Y: y}).In(content) {
+			// the picture has some texture, like a drawing
+			if (x/3+y/3)%2 == 0 {
+				return color.Gray{Y: 20}
+			}
+			return color.Gray{Y: 160}
+		}
+		return color.Gray{Y: 250}
+	}
+	switch kind {
+	case "YCbCr":
+		img := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				img.Y[img.YOffset(x, y)] = colorAt(x, y).Y
+				img.Cb[img.COffset(x, y)] = 128
+				img.Cr[img.COffset(x, y)] = 128
+			}
+		}
+		return img
+	case "Gray":
+		img := image.NewGray(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				img.SetGray(x, y, colorAt(x, y))
+			}
+		}
+		return img
+	}
+	img := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(x, y, colorAt(x, y))
+		}
+	}
+	return img
+}
+
+func TestCropImageBorders(t *testing.T) {
+	bounds := image.Rect(0, 0, 120, 160)
+	tests := []struct {
+		name    string
+		content image.Rectangle
+		rect    image.Rectangle
+		options func(*Options)
+		want    image.Rectangle
+	}{
+		{
+			name:    "borders on each side",
+			content: image.Rect(10, 20, 100, 150),
+			rect:    bounds,
+			want:    image.Rect(10, 20, 100, 150),
+		},
+		{
+			name:    "no border",
+			content: bounds,
+			rect:    bounds,
+			want:    bounds,
+		},
+		{
+			name:    "inside a rectangle",
+			content: image.Rect(30, 40, 90, 120),
+			rect:    image.Rect(20, 30, 110, 140),
+			want:    image.Rect(30, 40, 90, 120),
+		},
+		{
+			name:    "maximum crop",
+			content: image.Rect(40, 20, 100, 150),
+			rect:    bounds,
+			options: func(o *Options) { o.MaxCrop = 0.25 },
+			want:    image.Rect(30, 20, 100, 150),
+		},
+		{
+			name:    "symmetric",
+			content: image.Rect(10, 20, 100, 150),
+			rect:    bounds,
+			options: func(o *Options) { o.Symmetric = true },
+			want:    image.Rect(10, 10, 110, 150),
+		},
+		{
+			name:    "CIEDE2000",
+			content: image.Rect(10, 20, 100, 150),
+			rect:    bounds,
+			options: func(o *Options) { o.Comparator = ComparatorCIEDE2000 },
+			want:    image.Rect(10, 20, 100, 150),
+		},
+	}
+	for _, kind := range []string{"RGBA", "YCbCr", "Gray"} {
+		for _, test := range tests {
+			t.Run(kind+" "+test.name, func(t *testing.T) {
+				options := DefaultOptions
+				if test.options != nil {
+					test.options(&options)
+				}
+				img := page(kind, bounds, test.content)
+
+				rect := test.rect
+				CropImageBorders(img, &rect, options)
+				if rect != test.want {
+					t.Errorf("CropImageBorders() = %v, want %v", rect, test.want)
+				}
+				// the fast path reading the pixels must find the same borders as At
+				slow := test.rect
+				CropImageBorders(opaque{img}, &slow, options)
+				if slow != rect {
+					t.Errorf("CropImageBorders() = %v through At, %v through the fast path", slow, rect)
+				}
+			})
+		}
+	}
+}
+
+// cmpBaseline is CmpRGBComponents before the components were averaged, when only the blue difference was scaled
+func cmpBaseline(r1, g1, b1, r2, g2, b2 float64) float64 {
+	return (max(r1, r2) - min(r1, r2)) +
+		(max(g1, g2) - min(g1, g2)) +
+		(max(b1, b2)-min(b1, b2))/765.0
+}
+
+// The default threshold must find the same borders as the comparator used before, with its threshold of 0.10,
+// on gray pages whose picture is more or less close to the paper color
+func TestDefaultThresholdMatchesBaseline(t *testing.T) {
+	bounds := image.Rect(0, 0, 120, 160)
+	content := image.Rect(10, 20, 100, 150)
+	baseline := DefaultOptions
+	baseline.Threshold = 0.10
+
+	for _, ink := range []uint8{0, 100, 200, 225, 232, 236, 239, 243, 247} {
+		img := image.NewGray(bounds)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if (image.Point{X: x, Y: y}).In(content) {
+					img.SetGray(x, y, color.Gray{Y: ink})
+				} else {
+					img.SetGray(x, y, color.Gray{Y: 250})
+				}
+			}
+		}
+		read := sampling.NewReader(img)
+
+		want := bounds
+		cropBorders(read, &want, baseline, cmpBaseline)
+		got := bounds
+		CropImageBorders(img, &got, DefaultOptions)
+		if got != want {
+			t.Errorf("picture of gray %d : got %v, the baseline found %v", ink, got, want)
+		}
+	}
+}
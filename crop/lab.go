@@ -0,0 +1,102 @@
+package crop
+
+import "math"
+
+// lab converts a sRGB color (components in range 0.0..1.0) to the CIE L*a*b* color space, with a D65 white point
+func lab(r, g, b float64) (l, a, bb float64) {
+	linear := func(c float64) float64 {
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	r, g, b = linear(r), linear(g), linear(b)
+
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// maxLabDistance is the distance between black and white, used to bring the distances in range 0.0..1.0
+const maxLabDistance = 100.0
+
+// CmpCIE76 returns the euclidean distance of two colors in the L*a*b* color space
+func CmpCIE76(r1, g1, b1, r2, g2, b2 float64) float64 {
+	l1, a1, bb1 := lab(r1, g1, b1)
+	l2, a2, bb2 := lab(r2, g2, b2)
+	distance := math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (bb1-bb2)*(bb1-bb2))
+	return math.Min(1, distance/maxLabDistance)
+}
+
+// CmpCIEDE2000 returns the CIEDE2000 difference of two colors, closer to the perceived difference than CIE76
+func CmpCIEDE2000(r1, g1, b1, r2, g2, b2 float64) float64 {
+	l1, a1, bb1 := lab(r1, g1, b1)
+	l2, a2, bb2 := lab(r2, g2, b2)
+
+	c1 := math.Hypot(a1, bb1)
+	c2 := math.Hypot(a2, bb2)
+	meanC := (c1 + c2) / 2
+	g := 0.5 * (1 - math.Sqrt(math.Pow(meanC, 7)/(math.Pow(meanC, 7)+math.Pow(25, 7))))
+	a1, a2 = a1*(1+g), a2*(1+g)
+	c1, c2 = math.Hypot(a1, bb1), math.Hypot(a2, bb2)
+
+	hue := func(a, b float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := math.Atan2(b, a) * 180 / math.Pi
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	h1, h2 := hue(a1, bb1), hue(a2, bb2)
+
+	deltaL := l2 - l1
+	deltaC := c2 - c1
+	deltaH := 0.0
+	if c1*c2 != 0 {
+		deltaH = h2 - h1
+		if deltaH > 180 {
+			deltaH -= 360
+		} else if deltaH < -180 {
+			deltaH += 360
+		}
+	}
+	deltaHH := 2 * math.Sqrt(c1*c2) * math.Sin(deltaH/2*math.Pi/180)
+
+	meanL := (l1 + l2) / 2
+	meanC = (c1 + c2) / 2
+	meanH := h1 + h2
+	if c1*c2 != 0 {
+		if math.Abs(h1-h2) <= 180 {
+			meanH /= 2
+		} else if h1+h2 < 360 {
+			meanH = (h1 + h2 + 360) / 2
+		} else {
+			meanH = (h1 + h2 - 360) / 2
+		}
+	}
+
+	radians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	t := 1 - 0.17*math.Cos(radians(meanH-30)) + 0.24*math.Cos(radians(2*meanH)) +
+		0.32*math.Cos(radians(3*meanH+6)) - 0.20*math.Cos(radians(4*meanH-63))
+	deltaTheta := 30 * math.Exp(-math.Pow((meanH-275)/25, 2))
+	rc := 2 * math.Sqrt(math.Pow(meanC, 7)/(math.Pow(meanC, 7)+math.Pow(25, 7)))
+	sl := 1 + 0.015*(meanL-50)*(meanL-50)/math.Sqrt(20+(meanL-50)*(meanL-50))
+	sc := 1 + 0.045*meanC
+	sh := 1 + 0.015*meanC*t
+	rt := -math.Sin(radians(2*deltaTheta)) * rc
+
+	distance := math.Sqrt((deltaL/sl)*(deltaL/sl) + (deltaC/sc)*(deltaC/sc) + (deltaHH/sh)*(deltaHH/sh) + rt*(deltaC/sc)*(deltaHH/sh))
+	return math.Min(1, distance/maxLabDistance)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// distance in pixels of the window from an edge of the crop rectangle to grab it
+const cropHandleSize = 10.0
+
+// cropEditor edits the crop of the images of the current view with the mouse
+type cropEditor struct {
+	active bool
+	view   *ViewData
+	// imageIndex is the image of the view being cropped
+	imageIndex int
+	// rect is the part of the image kept, in image coordinates
+	rect pixel.Rect
+
+	// the edges moved by the current drag, a new rectangle is drawn when none is grabbed
+	dragging                 bool
+	left, right, top, bottom bool
+	// anchor is the corner of the new rectangle where the drag started, in image coordinates
+	anchor pixel.Vec
+	// previous is the rectangle before the drag, restored when a new rectangle is too small
+	previous pixel.Rect
+}
+
+// displayScale returns the scale of the current view when it is neither zoomed nor guided
+func (g *GogoReader) displayScale(view *ViewData) float64 {
+	return g.size.Y / view.maxHeight
+}
+
+// imageToScreen converts a point of an image of the current view, in image coordinates, to a point of the window
+func (g *GogoReader) imageToScreen(view *ViewData, imageIndex int, p pixel.Vec) pixel.Vec {
+	bounds, frame := view.imageBounds[imageIndex], view.imageFrames[imageIndex]
+	picture := pixel.V(p.X, bounds.Min.Y+bounds.Max.Y-p.Y)
+	layout := g.guided.positions[imageIndex].Add(picture.Sub(frame.Center()))
+	center := g.win.Bounds().Center()
+	return center.Add(layout.Sub(center).Scaled(g.displayScale(view)))
+}
+
+// screenToImage converts a point of the window to a point of an image of the current view, in image coordinates
+func (g *GogoReader) screenToImage(view *ViewData, imageIndex int, p pixel.Vec) pixel.Vec {
+	bounds, frame := view.imageBounds[imageIndex], view.imageFrames[imageIndex]
+	center := g.win.Bounds().Center()
+	layout := center.Add(p.Sub(center).Scaled(1 / g.displayScale(view)))
+	picture := layout.Sub(g.guided.positions[imageIndex]).Add(frame.Center())
+	return pixel.V(picture.X, bounds.Min.Y+bounds.Max.Y-picture.Y)
+}
+
+func clampToRect(p pixel.Vec, rect pixel.Rect) pixel.Vec {
+	return pixel.V(math.Max(rect.Min.X, math.Min(rect.Max.X, p.X)), math.Max(rect.Min.Y, math.Min(rect.Max.Y, p.Y)))
+}
+
+// imageAt returns the index of the image of the current view displayed at a point of the window, or -1
+func (g *GogoReader) imageAt(view *ViewData, p pixel.Vec) int {
+	for index := range view.imageFrames {
+		if index < len(g.guided.positions) && view.imageBounds[index].Contains(g.screenToImage(view, index, p)) {
+			return index
+		}
+	}
+	return -1
+}
+
+// startCropEditor displays the whole images of the current view to edit their crop
+func (g *GogoReader) startCropEditor() {
+	if g.guided.active {
+		g.notify("Leave the guided view to crop the pages")
+		return
+	}
+	view := album.GetCurrentView()
+	g.Zoom = false
+	g.cropEditor = cropEditor{active: true, view: view}
+	albumHistory.begin("crop")
+	view.editingCrop = true
+	g.needsRefresh = true
+	if err := g.refresh(); err != nil {
+		g.notifyError(err)
+		g.stopCropEditor(false)
+		return
+	}
+	g.selectCropImage(0)
+	g.notify("Drag to crop, Enter to apply, Escape to cancel")
+}
+
+// selectCropImage starts editing the crop of an image of the view, from the part of the image displayed
+func (g *GogoReader) selectCropImage(imageIndex int) {
+	cropRect := g.cropEditor.view.cropRects[imageIndex]
+	g.cropEditor.imageIndex = imageIndex
+	g.cropEditor.rect = pixel.R(float64(cropRect.Min.X), float64(cropRect.Min.Y), float64(cropRect.Max.X), float64(cropRect.Max.Y))
+}
+
+// applyCrop saves the rectangle of the editor as the crop of the image, relative to the crop of the album,
+// the borders found inside the rectangle are then kept
+func (g *GogoReader) applyCrop() {
+	view := g.cropEditor.view
+	imgData := view.Images[g.cropEditor.imageIndex]
+	b := view.imageBounds[g.cropEditor.imageIndex]
+	base, _ := baseCrop(imgData, image.Rect(int(b.Min.X), int(b.Min.Y), int(b.Max.X), int(b.Max.Y)))
+	rect := g.cropEditor.rect
+	imgData.Left = int(math.Max(0, math.Round(rect.Min.X)-float64(base.Min.X)))
+	imgData.Top = int(math.Max(0, math.Round(rect.Min.Y)-float64(base.Min.Y)))
+	imgData.Right = int(math.Max(0, float64(base.Max.X)-math.Round(rect.Max.X)))
+	imgData.Bottom = int(math.Max(0, float64(base.Max.Y)-math.Round(rect.Max.Y)))
+	imgData.ManualCrop = true
+}
+
+func (g *GogoReader) stopCropEditor(apply bool) {
+	if apply {
+		g.applyCrop()
+		g.notify("Crop applied")
+	}
+	g.cropEditor.view.editingCrop = false
+	g.cropEditor = cropEditor{}
+	albumHistory.commit(g.preferences.HistoryDepth)
+	g.needsRefresh = true
+}
+
+func (g *GogoReader) updateCropEditor() {
+	editor := &g.cropEditor
+	if album.GetCurrentView() != editor.view {
+		// the page was changed or deleted
+		g.stopCropEditor(false)
+		return
+	}
+	if g.win.JustPressed(pixelgl.KeyEscape) {
+		g.stopCropEditor(false)
+		g.notify("Crop cancelled")
+		return
+	}
+	if g.win.JustPressed(pixelgl.KeyEnter) || g.win.JustPressed(pixelgl.KeyKPEnter) {
+		g.stopCropEditor(true)
+		return
+	}
+	if g.win.JustPressed(pixelgl.KeyBackspace) {
+		// no crop at all
+		editor.rect = editor.view.imageBounds[editor.imageIndex]
+	}
+	if g.win.JustPressed(pixelgl.KeyTab) && len(editor.view.Images) > 1 {
+		// the crop of each image is applied when leaving it
+		g.applyCrop()
+		g.selectCropImage((editor.imageIndex + 1) % len(editor.view.Images))
+	}
+
+	mouse := g.win.MousePosition()
+	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
+		if index := g.imageAt(editor.view, mouse); index >= 0 && index != editor.imageIndex {
+			g.applyCrop()
+			g.selectCropImage(index)
+		}
+		// the Y axis goes down in the images and up in the window
+		topLeft := g.imageToScreen(editor.view, editor.imageIndex, editor.rect.Min)
+		bottomRight := g.imageToScreen(editor.view, editor.imageIndex, editor.rect.Max)
+		near := func(a, b float64) bool { return math.Abs(a-b) <= cropHandleSize }
+		inside := mouse.X >= topLeft.X-cropHandleSize && mouse.X <= bottomRight.X+cropHandleSize &&
+			mouse.Y >= bottomRight.Y-cropHandleSize && mouse.Y <= topLeft.Y+cropHandleSize
+		editor.left, editor.right = inside && near(mouse.X, topLeft.X), inside && near(mouse.X, bottomRight.X)
+		editor.top, editor.bottom = inside && near(mouse.Y, topLeft.Y), inside && near(mouse.Y, bottomRight.Y)
+		editor.anchor = clampToRect(g.screenToImage(editor.view, editor.imageIndex, mouse), editor.view.imageBounds[editor.imageIndex])
+		editor.previous = editor.rect
+		editor.dragging = true
+	}
+	if !editor.dragging {
+		return
+	}
+	if g.win.JustReleased(pixelgl.MouseButtonLeft) {
+		editor.dragging = false
+	}
+
+	p := clampToRect(g.screenToImage(editor.view, editor.imageIndex, mouse), editor.view.imageBounds[editor.imageIndex])
+	if !editor.left && !editor.right && !editor.top && !editor.bottom {
+		editor.rect = pixel.Rect{Min: editor.anchor, Max: p}.Norm()
+		if editor.rect.W() < 2 || editor.rect.H() < 2 {
+			// a click without drag
+			editor.rect = editor.previous
+		}
+		return
+	}
+	rect := editor.rect
+	if editor.left {
+		rect.Min.X = math.Min(p.X, rect.Max.X-1)
+	}
+	if editor.right {
+		rect.Max.X = math.Max(p.X, rect.Min.X+1)
+	}
+	if editor.top {
+		rect.Min.Y = math.Min(p.Y, rect.Max.Y-1)
+	}
+	if editor.bottom {
+		rect.Max.Y = math.Max(p.Y, rect.Min.Y+1)
+	}
+	editor.rect = rect
+}
+
+// drawCropEditor darkens the parts of the image which are cropped, and draws the handles of the crop rectangle
+func (g *GogoReader) drawCropEditor() {
+	editor := &g.cropEditor
+	if !editor.active || editor.view != album.GetCurrentView() || editor.imageIndex >= len(g.guided.positions) {
+		return
+	}
+	bounds := editor.view.imageBounds[editor.imageIndex]
+	outer := pixel.Rect{Min: g.imageToScreen(editor.view, editor.imageIndex, bounds.Min), Max: g.imageToScreen(editor.view, editor.imageIndex, bounds.Max)}.Norm()
+	inner := pixel.Rect{Min: g.imageToScreen(editor.view, editor.imageIndex, editor.rect.Min), Max: g.imageToScreen(editor.view, editor.imageIndex, editor.rect.Max)}.Norm()
+
+	imd := imdraw.New(nil)
+	imd.Color = color.RGBA{0, 0, 0, 160}
+	for _, r := range []pixel.Rect{
+		pixel.R(outer.Min.X, outer.Min.Y, outer.Max.X, inner.Min.Y),
+		pixel.R(outer.Min.X, inner.Max.Y, outer.Max.X, outer.Max.Y),
+		pixel.R(outer.Min.X, inner.Min.Y, inner.Min.X, inner.Max.Y),
+		pixel.R(inner.Max.X, inner.Min.Y, outer.Max.X, inner.Max.Y),
+	} {
+		if r.W() > 0 && r.H() > 0 {
+			imd.Push(r.Min, r.Max)
+			imd.Rectangle(0)
+		}
+	}
+	imd.Color = color.RGBA{90, 140, 200, 255}
+	imd.Push(inner.Min, inner.Max)
+	imd.Rectangle(2)
+	for _, x := range []float64{inner.Min.X, inner.Center().X, inner.Max.X} {
+		for _, y := range []float64{inner.Min.Y, inner.Center().Y, inner.Max.Y} {
+			if x == inner.Center().X && y == inner.Center().Y {
+				continue
+			}
+			imd.Push(pixel.V(x-cropHandleSize/2, y-cropHandleSize/2), pixel.V(x+cropHandleSize/2, y+cropHandleSize/2))
+			imd.Rectangle(0)
+		}
+	}
+	imd.Draw(g.win)
+}
@@ -0,0 +1,137 @@
+// Package deskew detects and corrects the skew of scanned pages
+package deskew
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// Options controls the skew detection
+type Options struct {
+	// MaxAngle is the largest skew detected, in degrees
+	MaxAngle float64
+	// Precision is the step of the final search, in degrees
+	Precision float64
+	// Width is the width the page is reduced to before the detection, in pixels
+	Width int
+}
+
+// DefaultOptions are the options used by the reader
+var DefaultOptions = Options{
+	MaxAngle:  5,
+	Precision: 0.05,
+	Width:     600,
+}
+
+// darkPoints returns the coordinates of the dark pixels of a reduced copy of the image, relative to its center
+func darkPoints(img image.Image, width int) [][2]float64 {
+	small := imaging.Grayscale(imaging.Resize(img, width, 0, imaging.Box))
+	bounds := small.Bounds()
+
+	// the ink is much darker than the average of the page
+	var sum float64
+	for i := 0; i < len(small.Pix); i += 4 {
+		sum += float64(small.Pix[i])
+	}
+	threshold := sum / float64(len(small.Pix)/4) * 0.6
+
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	var points [][2]float64
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if float64(small.Pix[small.PixOffset(x, y)]) < threshold {
+				points = append(points, [2]float64{float64(x) - cx, float64(y) - cy})
+			}
+		}
+	}
+	return points
+}
+
+// score measures how sharp the horizontal projection of the points is once rotated by angle degrees :
+// the lines of text and the borders of the panels give the sharpest profile when they are horizontal
+func score(points [][2]float64, angle float64) float64 {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	bins := make(map[int]float64)
+	minBin, maxBin := math.MaxInt32, math.MinInt32
+	for _, p := range points {
+		// vertical coordinate of the point once the image is rotated counter-clockwise
+		bin := int(math.Floor(p[1]*cos - p[0]*sin))
+		bins[bin]++
+		if bin < minBin {
+			minBin = bin
+		}
+		if bin > maxBin {
+			maxBin = bin
+		}
+	}
+	var result float64
+	for bin := minBin; bin < maxBin; bin++ {
+		diff := bins[bin+1] - bins[bin]
+		result += diff * diff
+	}
+	return result
+}
+
+// search returns the angle with the best score between from and to
+func search(points [][2]float64, from, to, step float64) float64 {
+	best, bestScore := 0.0, -1.0
+	for angle := from; angle <= to+step/2; angle += step {
+		if s := score(points, angle); s > bestScore {
+			best, bestScore = angle, s
+		}
+	}
+	return best
+}
+
+// Detect returns the angle, in degrees counter-clockwise, the image must be rotated by to straighten it
+func Detect(img image.Image, options Options) float64 {
+	if img.Bounds().Dx() < options.Width {
+		options.Width = img.Bounds().Dx()
+	}
+	points := darkPoints(img, options.Width)
+	if len(points) == 0 {
+		return 0
+	}
+	coarse := math.Max(options.Precision, options.MaxAngle/20)
+	angle := search(points, -options.MaxAngle, options.MaxAngle, coarse)
+	angle = search(points, angle-coarse, angle+coarse, options.Precision)
+	return math.Round(angle/options.Precision) * options.Precision
+}
+
+// BackgroundColor returns the median color of the borders of an image, used to fill the corners uncovered by the rotation
+func BackgroundColor(img image.Image) color.Color {
+	bounds := img.Bounds()
+	var reds, greens, blues []int
+	add := func(x, y int) {
+		r, g, b, _ := img.At(x, y).RGBA()
+		reds, greens, blues = append(reds, int(r>>8)), append(greens, int(g>>8)), append(blues, int(b>>8))
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x += 4 {
+		add(x, bounds.Min.Y)
+		add(x, bounds.Max.Y-1)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 4 {
+		add(bounds.Min.X, y)
+		add(bounds.Max.X-1, y)
+	}
+	if len(reds) == 0 {
+		return color.White
+	}
+	median := func(values []int) uint8 {
+		sort.Ints(values)
+		return uint8(values[len(values)/2])
+	}
+	return color.RGBA{median(reds), median(greens), median(blues), 255}
+}
+
+// Rotate rotates an image counter-clockwise by angle degrees, filling the corners with the color of its borders
+func Rotate(img image.Image, angle float64) image.Image {
+	if angle == 0 {
+		return img
+	}
+	return imaging.Rotate(img, angle, BackgroundColor(img))
+}
@@ -0,0 +1,67 @@
+package deskew
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// striped returns a page covered with lines of text : dark stripes broken into words
+func striped(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 245, G: 240, B: 225, A: 255}
+			inLine := y%24 >= 8 && y%24 < 14
+			inWord := (x/10)%7 != 6
+			margin := x < width/10 || x >= width*9/10 || y < height/10 || y >= height*9/10
+			if inLine && inWord && !margin {
+				c = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDetect(t *testing.T) {
+	page := striped(800, 1000)
+	tests := []struct {
+		name  string
+		angle float64
+	}{
+		{"straight", 0},
+		{"counter-clockwise", 2},
+		{"clockwise", -1.5},
+		{"small", 0.5},
+		{"large", 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Detect(Rotate(page, test.angle), DefaultOptions)
+			// the page is straightened by rotating it back
+			if math.Abs(got+test.angle) > 0.2 {
+				t.Errorf("Detect() = %.2f, want %.2f", got, -test.angle)
+			}
+		})
+	}
+}
+
+func TestDetectBlankPage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 300, 400))
+	for i := range img.Pix {
+		img.Pix[i] = 250
+	}
+	if got := Detect(img, DefaultOptions); got != 0 {
+		t.Errorf("Detect() = %.2f on a blank page, want 0", got)
+	}
+}
+
+func TestBackgroundColor(t *testing.T) {
+	page := striped(200, 300)
+	r, g, b, _ := BackgroundColor(page).RGBA()
+	if r>>8 != 245 || g>>8 != 240 || b>>8 != 225 {
+		t.Errorf("BackgroundColor() = (%d, %d, %d), want the color of the paper (245, 240, 225)", r>>8, g>>8, b>>8)
+	}
+}
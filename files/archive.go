@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 
 	"golang.org/x/image/webp"
 )
@@ -22,28 +23,45 @@ type ComicBookArchive interface {
 	Close()
 	List() ([]string, error)
 	ReadEntry(fileName string) (image.Image, error)
+	// ReadEntryUncached reads an image like ReadEntry, without keeping it in the image cache
+	ReadEntryUncached(fileName string) (image.Image, error)
 	GetMD5() string
 	Init() error
 }
 
 var imageCache map[string]image.Image
+var imageCacheMu sync.Mutex
+
+// DecodeImage decodes an image, using the file name to detect webp images.
+func DecodeImage(fileName string, reader io.Reader) (image.Image, error) {
+	if strings.HasSuffix(strings.ToLower(fileName), "webp") {
+		return webp.Decode(reader)
+	}
+	img, _, e := image.Decode(reader)
+	return img, e
+}
 
 func CreateImageFromReader(fileName string, reader io.Reader) (image.Image, error) {
+	return createImage(fileName, reader, true)
+}
+
+// createImage returns the decoded image from the image cache, or decodes it and
+// keeps it in the cache when cache is true.
+func createImage(fileName string, reader io.Reader, cache bool) (image.Image, error) {
+	imageCacheMu.Lock()
 	if imageCache == nil {
 		imageCache = make(map[string]image.Image)
 	}
 	img, hasKey := imageCache[fileName]
+	imageCacheMu.Unlock()
 	if hasKey {
 		return img, nil
 	}
-	var e error
-	if strings.HasSuffix(fileName, "webp") {
-		img, e = webp.Decode(reader)
-	} else {
-		img, _, e = image.Decode(reader)
-	}
-	if e == nil {
+	img, e := DecodeImage(fileName, reader)
+	if e == nil && cache {
+		imageCacheMu.Lock()
 		imageCache[fileName] = img
+		imageCacheMu.Unlock()
 	}
 	return img, e
 }
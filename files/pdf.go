@@ -48,6 +48,11 @@ func (P *PDFComicBook) ReadEntry(fileName string) (image.Image, error) {
 	return gimg, err
 }
 
+// ReadEntryUncached reads a page like ReadEntry, the pages of the PDF files are never cached
+func (P *PDFComicBook) ReadEntryUncached(fileName string) (image.Image, error) {
+	return P.ReadEntry(fileName)
+}
+
 func (P *PDFComicBook) GetMD5() string {
 	return P.MD5
 }
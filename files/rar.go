@@ -51,6 +51,14 @@ func (z *RaredComicBook) reload() error {
 }
 
 func (z *RaredComicBook) ReadEntry(fileName string) (image.Image, error) {
+	return z.readEntry(fileName, true)
+}
+
+func (z *RaredComicBook) ReadEntryUncached(fileName string) (image.Image, error) {
+	return z.readEntry(fileName, false)
+}
+
+func (z *RaredComicBook) readEntry(fileName string, cache bool) (image.Image, error) {
 
 	z.mu.Lock()
 	defer z.mu.Unlock()
@@ -70,7 +78,7 @@ func (z *RaredComicBook) ReadEntry(fileName string) (image.Image, error) {
 	var err error
 	for err != io.EOF {
 		if z.header.Name == fileName && z.header.UnPackedSize > 0 {
-			z.currentRawImage, err = CreateImageFromReader(fileName, z.archive)
+			z.currentRawImage, err = createImage(fileName, z.archive, cache)
 			return z.currentRawImage, err
 		}
 		z.header, err = z.archive.Next()
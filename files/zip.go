@@ -20,6 +20,14 @@ func (z *ZippedComicBook) GetMD5() string {
 }
 
 func (z *ZippedComicBook) ReadEntry(fileName string) (image.Image, error) {
+	return z.readEntry(fileName, true)
+}
+
+func (z *ZippedComicBook) ReadEntryUncached(fileName string) (image.Image, error) {
+	return z.readEntry(fileName, false)
+}
+
+func (z *ZippedComicBook) readEntry(fileName string, cache bool) (image.Image, error) {
 	for _, f := range z.zip.File {
 		if f.Name == fileName {
 			rc, err := f.Open()
@@ -27,7 +35,7 @@ func (z *ZippedComicBook) ReadEntry(fileName string) (image.Image, error) {
 				return nil, err
 			}
 			defer rc.Close()
-			return CreateImageFromReader(fileName, rc)
+			return createImage(fileName, rc, cache)
 		}
 	}
 	return nil, fmt.Errorf("file %s was not found in archive", fileName)
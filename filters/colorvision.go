@@ -0,0 +1,92 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/disintegration/imaging"
+)
+
+// Deficiency is a type of color vision deficiency
+type Deficiency string
+
+const (
+	DeficiencyNone Deficiency = ""
+	// Protan, Deutan and Tritan are the deficiencies of the long (red), medium (green) and short (blue) wavelength cones
+	Protan Deficiency = "protan"
+	Deutan Deficiency = "deutan"
+	Tritan Deficiency = "tritan"
+)
+
+// Deficiencies lists the deficiencies, in the order they are selected
+var Deficiencies = []Deficiency{DeficiencyNone, Protan, Deutan, Tritan}
+
+func (d Deficiency) String() string {
+	if d == DeficiencyNone {
+		return "none"
+	}
+	return string(d)
+}
+
+type matrix [3][3]float64
+
+func (m matrix) multiply(other matrix) matrix {
+	var result matrix
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result[i][j] += m[i][k] * other[k][j]
+			}
+		}
+	}
+	return result
+}
+
+func (m matrix) apply(r, g, b float64) (float64, float64, float64) {
+	return m[0][0]*r + m[0][1]*g + m[0][2]*b,
+		m[1][0]*r + m[1][1]*g + m[1][2]*b,
+		m[2][0]*r + m[2][1]*g + m[2][2]*b
+}
+
+var (
+	rgbToLMS = matrix{
+		{17.8824, 43.5161, 4.11935},
+		{3.45565, 27.1554, 3.86714},
+		{0.0299566, 0.184309, 1.46709},
+	}
+	lmsToRGB = matrix{
+		{0.0809444479, -0.130504409, 0.116721066},
+		{-0.0102485335, 0.0540193266, -0.113614708},
+		{-0.000365296938, -0.00412161469, 0.693511405},
+	}
+	// projections of the colors in the LMS space on the colors seen with each deficiency
+	lmsSimulations = map[Deficiency]matrix{
+		Protan: {{0, 2.02344, -2.52581}, {0, 1, 0}, {0, 0, 1}},
+		Deutan: {{1, 0, 0}, {0.494207, 0, 1.24827}, {0, 0, 1}},
+		Tritan: {{1, 0, 0}, {0, 1, 0}, {-0.395913, 0.801109, 0}},
+	}
+	// errorShift moves the colors which are not seen to the visible channels
+	errorShift = matrix{{0, 0, 0}, {0.7, 1, 0}, {0.7, 0, 1}}
+)
+
+// simulation returns the matrix converting RGB colors to the colors seen with the deficiency
+func (d Deficiency) simulation() matrix {
+	return lmsToRGB.multiply(lmsSimulations[d]).multiply(rgbToLMS)
+}
+
+// ApplyColorVision corrects the colors of an image for the deficiency, or simulates how the image is seen with it
+func ApplyColorVision(img image.Image, deficiency Deficiency, simulate bool) image.Image {
+	if _, found := lmsSimulations[deficiency]; !found {
+		return img
+	}
+	simulation := deficiency.simulation()
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		r, g, b := float64(c.R), float64(c.G), float64(c.B)
+		sr, sg, sb := simulation.apply(r, g, b)
+		if simulate {
+			return color.NRGBA{R: clamp(sr), G: clamp(sg), B: clamp(sb), A: c.A}
+		}
+		er, eg, eb := errorShift.apply(r-sr, g-sg, b-sb)
+		return color.NRGBA{R: clamp(r + er), G: clamp(g + eg), B: clamp(b + eb), A: c.A}
+	})
+}
@@ -0,0 +1,37 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyColorVision(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	grays := []uint8{0, 60, 180, 255}
+	for x, v := range grays {
+		img.SetNRGBA(x, 0, color.NRGBA{R: v, G: v, B: v, A: 255})
+	}
+	if got := ApplyColorVision(img, DeficiencyNone, false); got != image.Image(img) {
+		t.Error("ApplyColorVision() modified the image without deficiency")
+	}
+	// the grays are seen the same way with every deficiency, and are left unchanged
+	for _, deficiency := range []Deficiency{Protan, Deutan, Tritan} {
+		for _, simulate := range []bool{false, true} {
+			got := ApplyColorVision(img, deficiency, simulate)
+			for x, v := range grays {
+				c := color.NRGBAModel.Convert(got.At(x, 0)).(color.NRGBA)
+				if absDiff(c.R, v) > 2 || absDiff(c.G, v) > 2 || absDiff(c.B, v) > 2 {
+					t.Errorf("ApplyColorVision(%s, %t) turned gray %d into %v", deficiency, simulate, v, c)
+				}
+			}
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,154 @@
+// Package filters contains the corrections applied to the scanned pages
+package filters
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/mozvip/gomics/sampling"
+)
+
+// LevelsOptions controls the automatic levels correction
+type LevelsOptions struct {
+	// BlackClip is the ratio of the darkest pixels turned to black
+	BlackClip float64
+	// WhiteClip is the ratio of the brightest pixels turned to white, when no paper color is found
+	WhiteClip float64
+	// NormalizePaper turns the paper color to white, removing the color cast of yellowed pages
+	NormalizePaper bool
+	// MinRange is the minimum difference between the black and white points for the correction to be applied
+	MinRange int
+	// Step is the distance in pixels between the pixels sampled to compute the histogram
+	Step int
+}
+
+// DefaultLevelsOptions are the options used by the reader
+var DefaultLevelsOptions = LevelsOptions{
+	BlackClip: 0.005,
+	WhiteClip: 0.005,
+	MinRange:  48,
+	Step:      2,
+}
+
+// Levels are the black point and the white points (per channel) of an image
+type Levels struct {
+	Black int
+	White [3]int
+}
+
+// luminance returns the luminance of a color, components being in range 0..255
+func luminance(r, g, b int) int {
+	return (299*r + 587*g + 114*b + 500) / 1000
+}
+
+// to8 converts a component in range 0.0..1.0 to range 0..255
+func to8(v float64) int {
+	return int(v*255 + 0.5)
+}
+
+// DetectLevels computes the levels of an image from the histogram of its luminance : the black point
+// clips the darkest pixels, the white point is the color of the paper (the most frequent bright
+// color) or clips the brightest pixels on dark pages
+func DetectLevels(img image.Image, options LevelsOptions) Levels {
+	if options.Step < 1 {
+		options.Step = 1
+	}
+	var histogram [256]int
+	var sums [256][3]float64
+	total := 0
+	bounds := img.Bounds()
+	read := sampling.NewReader(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += options.Step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += options.Step {
+			rf, gf, bf := read(x, y)
+			r, g, b := to8(rf), to8(gf), to8(bf)
+			l := luminance(r, g, b)
+			histogram[l]++
+			sums[l][0] += float64(r)
+			sums[l][1] += float64(g)
+			sums[l][2] += float64(b)
+			total++
+		}
+	}
+
+	levels := Levels{White: [3]int{255, 255, 255}}
+	if total == 0 {
+		return levels
+	}
+
+	count := 0
+	for l := 0; l < 256; l++ {
+		count += histogram[l]
+		if float64(count) > float64(total)*options.BlackClip {
+			levels.Black = l
+			break
+		}
+	}
+
+	// the paper is the highest peak of the bright half of the histogram, if it covers enough of the page
+	paper, paperCount := -1, 0
+	for l := 128; l < 256; l++ {
+		if histogram[l] > paperCount {
+			paper, paperCount = l, histogram[l]
+		}
+	}
+	white := 255
+	if paper >= 0 && float64(paperCount) > float64(total)*0.02 {
+		white = paper
+	} else {
+		count = 0
+		for l := 255; l >= 0; l-- {
+			count += histogram[l]
+			if float64(count) > float64(total)*options.WhiteClip {
+				white = l
+				break
+			}
+		}
+	}
+	levels.White = [3]int{white, white, white}
+
+	if options.NormalizePaper && paper >= 0 && white == paper {
+		// average color of the paper, around its luminance
+		var sum [3]float64
+		var paperPixels int
+		for l := int(math.Max(128, float64(paper-4))); l <= int(math.Min(255, float64(paper+4))); l++ {
+			for c := 0; c < 3; c++ {
+				sum[c] += sums[l][c]
+			}
+			paperPixels += histogram[l]
+		}
+		if paperPixels > 0 {
+			for c := 0; c < 3; c++ {
+				levels.White[c] = int(math.Round(sum[c] / float64(paperPixels)))
+			}
+		}
+	}
+	return levels
+}
+
+// AutoLevels stretches the colors of an image between its black and white points, the image is
+// returned unchanged when the levels are too close to each other to be reliable
+func AutoLevels(img image.Image, options LevelsOptions) image.Image {
+	levels := DetectLevels(img, options)
+
+	var lookup [3][256]uint8
+	for c := 0; c < 3; c++ {
+		white := levels.White[c]
+		if white-levels.Black < options.MinRange {
+			return img
+		}
+		for v := 0; v < 256; v++ {
+			value := float64(v-levels.Black) * 255 / float64(white-levels.Black)
+			lookup[c][v] = uint8(math.Max(0, math.Min(255, math.Round(value))))
+		}
+	}
+	if levels.Black == 0 && levels.White == [3]int{255, 255, 255} {
+		return img
+	}
+
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{R: lookup[0][c.R], G: lookup[1][c.G], B: lookup[2][c.B], A: c.A}
+	})
+}
@@ -0,0 +1,109 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// scan returns a page of yellowed paper with ink covering a ratio of the pixels
+func scan(paper, ink color.RGBA, inkRatio float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	inkRows := int(200 * inkRatio)
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			if y < inkRows {
+				img.SetRGBA(x, y, ink)
+			} else {
+				img.SetRGBA(x, y, paper)
+			}
+		}
+	}
+	return img
+}
+
+func TestDetectLevels(t *testing.T) {
+	paper, ink := color.RGBA{R: 230, G: 220, B: 190, A: 255}, color.RGBA{R: 30, G: 30, B: 30, A: 255}
+	tests := []struct {
+		name    string
+		img     image.Image
+		options func(*LevelsOptions)
+		want    Levels
+	}{
+		{
+			name: "paper and ink",
+			img:  scan(paper, ink, 0.3),
+			want: Levels{Black: 30, White: [3]int{220, 220, 220}},
+		},
+		{
+			name:    "paper normalized",
+			img:     scan(paper, ink, 0.3),
+			options: func(o *LevelsOptions) { o.NormalizePaper = true },
+			want:    Levels{Black: 30, White: [3]int{230, 220, 190}},
+		},
+		{
+			name: "dark page without paper",
+			img:  scan(color.RGBA{R: 100, G: 100, B: 100, A: 255}, color.RGBA{R: 10, G: 10, B: 10, A: 255}, 0.5),
+			want: Levels{Black: 10, White: [3]int{100, 100, 100}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := DefaultLevelsOptions
+			if test.options != nil {
+				test.options(&options)
+			}
+			if got := DetectLevels(test.img, options); got != test.want {
+				t.Errorf("DetectLevels() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAutoLevels(t *testing.T) {
+	tests := []struct {
+		name           string
+		paper, ink     color.RGBA
+		wantPaper      color.NRGBA
+		wantInk        color.NRGBA
+		wantUnmodified bool
+	}{
+		{
+			name:      "stretched",
+			paper:     color.RGBA{R: 200, G: 200, B: 200, A: 255},
+			ink:       color.RGBA{R: 40, G: 40, B: 40, A: 255},
+			wantPaper: color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+			wantInk:   color.NRGBA{R: 0, G: 0, B: 0, A: 255},
+		},
+		{
+			name:           "range too small",
+			paper:          color.RGBA{R: 140, G: 140, B: 140, A: 255},
+			ink:            color.RGBA{R: 120, G: 120, B: 120, A: 255},
+			wantUnmodified: true,
+		},
+		{
+			name:           "already stretched",
+			paper:          color.RGBA{R: 255, G: 255, B: 255, A: 255},
+			ink:            color.RGBA{R: 0, G: 0, B: 0, A: 255},
+			wantUnmodified: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := scan(test.paper, test.ink, 0.3)
+			got := AutoLevels(img, DefaultLevelsOptions)
+			if test.wantUnmodified {
+				if got != image.Image(img) {
+					t.Error("AutoLevels() modified the image")
+				}
+				return
+			}
+			if c := color.NRGBAModel.Convert(got.At(0, 199)); c != test.wantPaper {
+				t.Errorf("paper = %v, want %v", c, test.wantPaper)
+			}
+			if c := color.NRGBAModel.Convert(got.At(0, 0)); c != test.wantInk {
+				t.Errorf("ink = %v, want %v", c, test.wantInk)
+			}
+		})
+	}
+}
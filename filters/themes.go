@@ -0,0 +1,61 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// Theme changes the colors of the pages, for reading at night
+type Theme string
+
+const (
+	ThemeNone Theme = ""
+	// ThemeInvert inverts the lightness of the colors, keeping their hue
+	ThemeInvert Theme = "invert"
+	ThemeSepia  Theme = "sepia"
+	// ThemeWarm reduces the blue light
+	ThemeWarm Theme = "warm"
+)
+
+// Themes lists the themes, in the order they are selected
+var Themes = []Theme{ThemeNone, ThemeInvert, ThemeSepia, ThemeWarm}
+
+func (t Theme) String() string {
+	if t == ThemeNone {
+		return "none"
+	}
+	return string(t)
+}
+
+// Color returns a color of a page once the theme is applied
+func (t Theme) Color(c color.NRGBA) color.NRGBA {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	switch t {
+	case ThemeInvert:
+		// moving the channels by the same amount keeps the hue and the chroma, the lightness is inverted
+		shift := 255 - math.Max(r, math.Max(g, b)) - math.Min(r, math.Min(g, b))
+		r, g, b = r+shift, g+shift, b+shift
+	case ThemeSepia:
+		r, g, b = 0.393*r+0.769*g+0.189*b, 0.349*r+0.686*g+0.168*b, 0.272*r+0.534*g+0.131*b
+	case ThemeWarm:
+		g, b = g*0.85, b*0.6
+	default:
+		return c
+	}
+	return color.NRGBA{R: clamp(r), G: clamp(g), B: clamp(b), A: c.A}
+}
+
+func clamp(value float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(value))))
+}
+
+// Apply returns the image with the theme applied to its colors
+func (t Theme) Apply(img image.Image) image.Image {
+	if t == ThemeNone {
+		return img
+	}
+	return imaging.AdjustFunc(img, t.Color)
+}
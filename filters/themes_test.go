@@ -0,0 +1,29 @@
+package filters
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestThemeColor(t *testing.T) {
+	white, black := color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.NRGBA{A: 255}
+	red := color.NRGBA{R: 200, G: 40, B: 40, A: 128}
+	tests := []struct {
+		theme Theme
+		in    color.NRGBA
+		want  color.NRGBA
+	}{
+		{ThemeNone, red, red},
+		{ThemeInvert, white, black},
+		{ThemeInvert, black, white},
+		// the hue and the alpha are kept
+		{ThemeInvert, red, color.NRGBA{R: 215, G: 55, B: 55, A: 128}},
+		{ThemeSepia, white, color.NRGBA{R: 255, G: 255, B: 239, A: 255}},
+		{ThemeWarm, white, color.NRGBA{R: 255, G: 217, B: 153, A: 255}},
+	}
+	for _, test := range tests {
+		if got := test.theme.Color(test.in); got != test.want {
+			t.Errorf("%s.Color(%v) = %v, want %v", test.theme, test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// maxGamepadDeadzone is the largest dead zone accepted from the preferences, the sticks ranging from -1 to 1
+const maxGamepadDeadzone = 0.95
+
+// connectedGamepads returns the joysticks currently connected
+func connectedGamepads(win *pixelgl.Window) []pixelgl.Joystick {
+	var joysticks []pixelgl.Joystick
+	for joystick := pixelgl.Joystick1; joystick <= pixelgl.JoystickLast; joystick++ {
+		if win.JoystickPresent(joystick) {
+			joysticks = append(joysticks, joystick)
+		}
+	}
+	return joysticks
+}
+
+// gamepadJustPressed returns true when a button was pressed on any connected gamepad, used by the overlays
+func (g *GogoReader) gamepadJustPressed(button pixelgl.GamepadButton) bool {
+	for _, joystick := range connectedGamepads(g.win) {
+		if g.win.JoystickJustPressed(joystick, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// gamepadAxis returns the value of the axis on the first gamepad moving it beyond the dead zone, or 0
+func (g *GogoReader) gamepadAxis(axis pixelgl.GamepadAxis) float64 {
+	for _, joystick := range connectedGamepads(g.win) {
+		value := g.win.JoystickAxis(joystick, axis)
+		if math.Abs(value) > g.preferences.GamepadDeadzone {
+			// rescale so that the movement starts smoothly at the edge of the dead zone
+			return math.Copysign((math.Abs(value)-g.preferences.GamepadDeadzone)/(1-g.preferences.GamepadDeadzone), value)
+		}
+	}
+	return 0
+}
+
+// updateGamepad pans the zoomed page with the analog stick configured in the preferences
+func (g *GogoReader) updateGamepad() {
+	if !g.Zoom {
+		return
+	}
+	axis := pixelgl.AxisLeftY
+	if g.preferences.GamepadPanStick == "right" {
+		axis = pixelgl.AxisRightY
+	}
+	move := g.gamepadAxis(axis)
+	if move == 0 {
+		return
+	}
+
+	maxHeight := album.GetCurrentView().maxHeight
+	// the Y axis is negative when the stick is pushed up, to show the top of the page
+	g.ZoomPositionY -= move * g.preferences.GamepadPanSpeed
+	g.ZoomPositionY = math.Max(g.size.Y-maxHeight, math.Min(g.ZoomPositionY, maxHeight))
+}
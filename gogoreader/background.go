@@ -5,15 +5,24 @@ import (
 	"math"
 
 	"github.com/faiface/pixel"
+	"github.com/mozvip/gomics/sampling"
 )
 
 func AverageColor(pictureData *pixel.PictureData, rect image.Rectangle) pixel.RGBA {
-	step := 3.0
+	return averageColor(sampling.NewPictureReader(pictureData), rect)
+}
+
+// AverageImageColor returns the average color of the rect part of an image, white pixels excluded
+func AverageImageColor(img image.Image, rect image.Rectangle) pixel.RGBA {
+	return averageColor(sampling.NewReader(img), rect)
+}
+
+func averageColor(read sampling.Reader, rect image.Rectangle) pixel.RGBA {
+	const step = 3
 	var count, sr, sg, sb float64
-	for x := float64(rect.Min.X); x < float64(rect.Max.X); x += step {
-		for y := float64(rect.Min.Y); y < float64(rect.Max.Y); y += step {
-			rgba := pictureData.Color(pixel.Vec{X: x, Y: y})
-			r, g, b := rgba.R, rgba.G, rgba.B
+	for x := rect.Min.X; x < rect.Max.X; x += step {
+		for y := rect.Min.Y; y < rect.Max.Y; y += step {
+			r, g, b := read(x, y)
 			if r > 0.95 && g > 0.95 && b > 0.95 {
 				// ignore white pixels
 				continue
@@ -33,15 +42,23 @@ func AverageColor(pictureData *pixel.PictureData, rect image.Rectangle) pixel.RG
 }
 
 func ProminentColor(pictureData *pixel.PictureData, rect image.Rectangle) pixel.RGBA {
-	step := 3.0
+	return prominentColor(sampling.NewPictureReader(pictureData), rect)
+}
+
+// ProminentImageColor returns the most frequent color of the rect part of an image, white and black pixels excluded
+func ProminentImageColor(img image.Image, rect image.Rectangle) pixel.RGBA {
+	return prominentColor(sampling.NewReader(img), rect)
+}
+
+func prominentColor(read sampling.Reader, rect image.Rectangle) pixel.RGBA {
+	const step = 3
 
 	colorsCount := make(map[pixel.RGBA]uint)
 	currentMax := uint(0)
 	prominentColor := pixel.RGB(0, 0, 0)
-	for x := float64(rect.Min.X); x < float64(rect.Max.X); x += step {
-		for y := float64(rect.Min.Y); y < float64(rect.Max.Y); y += step {
-			rgba := pictureData.Color(pixel.Vec{X: float64(x), Y: float64(y)})
-			r, g, b := rgba.R, rgba.G, rgba.B
+	for x := rect.Min.X; x < rect.Max.X; x += step {
+		for y := rect.Min.Y; y < rect.Max.Y; y += step {
+			r, g, b := read(x, y)
 			if r > 0.95 && g > 0.95 && b > 0.95 {
 				// ignore white pixels
 				continue
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
@@ -9,7 +10,10 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime/pprof"
+	"sync"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/faiface/pixel"
@@ -17,7 +21,9 @@ import (
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/faiface/pixel/text"
 	"github.com/mozvip/gomics/crop"
+	"github.com/mozvip/gomics/deskew"
 	"github.com/mozvip/gomics/files"
+	"github.com/mozvip/gomics/filters"
 	"github.com/mozvip/gomics/gogoreader"
 	"github.com/mozvip/gomics/resources"
 	"github.com/mozvip/gomics/ui"
@@ -34,10 +40,62 @@ type GogoReader struct {
 	ZoomPositionX float64
 	ZoomPositionY float64
 
+	gridDisplay bool
+	// gridHidden shows the hidden images in the thumbnails instead of the pages
+	gridHidden bool
+	gridScroll float64
+	// gridSelection is the thumbnail selected with the keyboard or the gamepad
+	gridSelection int
+	thumbnails    *thumbnailLoader
+
+	dialog          *ui.InputDialog
+	dialogConfirm   func(value string)
+	scrubber        ui.Scrubber
+	scrubberVisible bool
+
+	dragging  bool
+	dragStart pixel.Vec
+
+	// resizedAt is the time the window was resized, until the images are scaled to the new size
+	resizedAt time.Time
+	// resampled receives the sprites of the views scaled down in the background
+	resampled chan *resampledView
+
+	// the consistent crop of the album is computed in the background
+	albumCropRunning bool
+	albumCropResult  chan *AlbumCrop
+
+	// blank and duplicated pages are looked for in the background
+	pageAnalysisRunning bool
+	pageAnalysisResult  chan *pageAnalysis
+
+	slideshow slideshow
+	guided    guidedView
+
+	transition           transition
+	lastFrame            []drawnSprite
+	lastFrameTime        time.Time
+	averageFrameDuration time.Duration
+
+	bookmarksDisplay  bool
+	bookmarkSelection int
+
+	helpDisplay bool
+	helpScroll  float64
+
+	cropEditor cropEditor
+
+	adjustmentsDisplay bool
+	adjustSelection    int
+	// adjustPage is true when the adjustments of the current view are edited instead of those of the album
+	adjustPage bool
+
 	fatalErr error
 
-	messages []ui.Message
-	win      *pixelgl.Window
+	messages   []ui.Message
+	messagesMu sync.Mutex
+	win        *pixelgl.Window
+	keyMap     *KeyMap
 }
 
 var fontAtlas *text.Atlas
@@ -58,16 +116,11 @@ func (g *GogoReader) toggleInfoDisplay() {
 	g.infoDisplay = !g.infoDisplay
 }
 
-func (g *GogoReader) crop(key pixelgl.Button) int {
-	speed := 0
-	if g.win.JustPressed(key) {
-		speed = 1
-	}
-	if g.win.Repeated(key) {
-		speed = 2
+func onOff(value bool) string {
+	if value {
+		return "on"
 	}
-	g.needsRefresh = speed > 0
-	return speed
+	return "off"
 }
 
 func (g *GogoReader) Update() error {
@@ -76,130 +129,48 @@ func (g *GogoReader) Update() error {
 		return nil
 	}
 
-	album.GetCurrentView().Images[0].Top += g.crop(pixelgl.KeyUp)
-	album.GetCurrentView().Images[0].Bottom += g.crop(pixelgl.KeyDown)
-	album.GetCurrentView().Images[0].Left += g.crop(pixelgl.KeyLeft)
-	album.GetCurrentView().Images[0].Right += g.crop(pixelgl.KeyRight)
-
-	if g.win.JustPressed(pixelgl.KeyI) {
-		g.toggleInfoDisplay()
-	}
-
-	if g.win.JustPressed(pixelgl.KeyF1) {
-		g.win.SetSmooth(true)
-		g.needsRefresh = true
-	}
-
-	if g.win.JustPressed(pixelgl.KeyF2) {
-		g.win.SetSmooth(false)
-		g.needsRefresh = true
-	}
-
-	if (g.win.JustPressed(pixelgl.KeyPageUp) || g.win.MouseScroll().Y > 0) && album.CurrentViewIndex > 0 {
-		g.PreviousPage()
-	}
-
-	if (g.win.JustPressed(pixelgl.KeyPageDown) || g.win.MouseScroll().Y < 0) && album.CurrentViewIndex < len(album.Views)-1 {
-		g.NextPage()
-	}
-
-	if g.win.JustPressed(pixelgl.KeyDelete) {
-		// remove current page
-		album.Views = append(album.Views[:album.CurrentViewIndex], album.Views[album.CurrentViewIndex+1:]...)
-		g.needsRefresh = true
-	}
-
-	if g.win.JustPressed(pixelgl.KeyHome) {
-		// go to the first page
-		g.goTo(0)
-	}
-
-	if g.win.JustPressed(pixelgl.KeyEnd) {
-		// go to the last page
-		g.goTo(len(album.Views) - 1)
-	}
-
-	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
-		g.Zoom = !g.Zoom
-		g.ZoomPositionX = g.win.Bounds().Center().X
+	if g.gridDisplay {
+		g.updateGrid()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyL) {
-		album.GetCurrentView().RotateLeft()
-		g.needsRefresh = true
-	}
-	if g.win.JustPressed(pixelgl.KeyR) {
-		album.GetCurrentView().RotateRight()
-		g.needsRefresh = true
+	if g.dialog != nil {
+		g.updateDialog()
+		g.updateScrubber()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyG) {
-		album.GrayScale = !album.GrayScale
-	}
-
-	if g.win.JustPressed(pixelgl.KeyB) {
-		if g.win.Pressed(pixelgl.KeyLeftShift) || g.win.Pressed(pixelgl.KeyRightShift) {
-			// only for the current page
-			album.GetCurrentView().ToggleBorder(g.preferences.RemoveBorders)
-		} else {
-			g.preferences.RemoveBorders = !g.preferences.RemoveBorders
-		}
-		g.needsRefresh = true
+	if g.bookmarksDisplay {
+		g.updateBookmarks()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyBackspace) {
-		album.Reset()
-		g.needsRefresh = true
+	if g.helpDisplay {
+		g.updateHelp()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyMinus) {
-		album.GetCurrentView().RotationAngle -= 0.05
-		g.needsRefresh = true
+	if g.adjustmentsDisplay {
+		g.updateAdjustments()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyPeriod) {
-		album.GetCurrentView().RotationAngle += 0.05
-		g.needsRefresh = true
+	if g.cropEditor.active {
+		g.updateCropEditor()
+		return g.refresh()
 	}
 
-	if g.win.JustPressed(pixelgl.KeyKPDivide) {
-		album.GetCurrentView().RotationAngle = 0
-		g.needsRefresh = true
-	}
+	g.checkResize()
+	g.updateAlbumCrop()
+	g.updatePageAnalysis()
+	mouseUsed := g.updateScrubber()
+	g.runActions(mouseUsed)
+	g.updateMouse(mouseUsed)
+	g.updateGamepad()
 
-	if g.win.JustPressed(pixelgl.KeyF11) || g.win.JustPressed(pixelgl.KeyF) {
-		if !g.preferences.FullScreen {
-			// save the current size of the window
-			g.preferences.WindowedSize = g.win.Bounds().Size()
-		}
-		g.preferences.FullScreen = !g.preferences.FullScreen
-		g.ToggleFullScreen()
-	}
-
-	if g.win.JustPressed(pixelgl.KeyEscape) || g.win.JustPressed(pixelgl.KeyQ) {
-		AppQuit(g.preferences)
-	}
-
-	if g.win.JustPressed(pixelgl.KeyD) {
-		if len(album.GetCurrentView().Images) == 1 && album.CurrentViewIndex < len(album.Views)-1 {
-			// only if we have a page after the current one
-			album.GetCurrentView().Images = append(album.GetCurrentView().Images, album.Views[album.CurrentViewIndex+1].Images...)
-			album.Views = append(album.Views[:album.CurrentViewIndex+1], album.Views[album.CurrentViewIndex+2:]...)
-		} else if len(album.GetCurrentView().Images) > 1 {
-			// create a new page with only the second image
-			newPage := ViewData{Images: album.GetCurrentView().Images[1:]}
-			// only keep the first image on the current page
-			album.GetCurrentView().Images = album.GetCurrentView().Images[:1]
-
-			// allocate one more page
-			album.Views = append(album.Views[:album.CurrentViewIndex+1], album.Views[album.CurrentViewIndex:]...)
-			// next page is the new page
-			album.Views[album.CurrentViewIndex+1] = &newPage
-		}
-		g.needsRefresh = true
-	}
-
-	return g.refresh()
+	err := g.refresh()
+	g.updateSlideshow()
+	return err
 }
 
 func (g *GogoReader) drawBackGround() {
@@ -220,6 +191,13 @@ func (g *GogoReader) drawBackGround() {
 
 func (g *GogoReader) Draw() {
 
+	g.updateFrameDuration()
+
+	if g.gridDisplay {
+		g.drawGrid()
+		return
+	}
+
 	// draw background
 	g.drawBackGround()
 
@@ -231,7 +209,7 @@ func (g *GogoReader) Draw() {
 	// draw scaled images
 	scale := g.size.Y / maxHeight
 
-	if g.Zoom {
+	if g.Zoom && !g.slideshow.scrolling {
 		mousePosition := g.win.MousePosition()
 
 		// FIXME : use a percentage of the total height instead of absolute 100
@@ -248,23 +226,41 @@ func (g *GogoReader) Draw() {
 	}
 
 	center := g.win.Bounds().Center()
-	positions := make([]pixel.Vec, 0, len(currentView.imageSprites))
+	positions := make([]pixel.Vec, len(currentView.imageSprites))
 	startX := center.X - (totalWidth / 2.0)
-	for _, sprite := range currentView.imageSprites {
-		var imageW = sprite.Frame().W()
-		positions = append(positions, pixel.Vec{X: startX + imageW/2.0, Y: center.Y})
-		startX += sprite.Frame().W()
+	for i := range currentView.imageSprites {
+		// in right to left mode, the first image is displayed on the right
+		index := i
+		if album.RightToLeft {
+			index = len(currentView.imageSprites) - 1 - i
+		}
+		var imageW = currentView.imageFrames[index].W()
+		positions[index] = pixel.Vec{X: startX + imageW/2.0, Y: center.Y}
+		startX += imageW
 	}
+	camera, panel, guided := g.guidedCamera(currentView, positions)
+	sprites := make([]drawnSprite, 0, len(currentView.imageSprites))
 	for index, sprite := range currentView.imageSprites {
-		matrix := pixel.IM.Moved(positions[index])
-		if g.Zoom {
+		matrix := currentView.spriteMatrix(index).Moved(positions[index])
+		if guided {
+			matrix = currentView.spriteMatrix(index).Chained(camera.matrix(g.win, positions[index]))
+		} else if g.Zoom {
 			scale = g.win.Bounds().W() / totalWidth
-			matrix = matrix.Scaled(pixel.V(g.ZoomPositionX, g.ZoomPositionY), scale)
+			if zoomTranslates(scale) {
+				matrix = matrix.Moved(pixel.V(0, g.win.Bounds().Center().Y-g.ZoomPositionY))
+			} else {
+				matrix = matrix.Scaled(pixel.V(g.ZoomPositionX, g.ZoomPositionY), scale)
+			}
 		} else {
 			matrix = matrix.Scaled(g.win.Bounds().Center(), scale)
 		}
-		sprite.Draw(g.win, matrix)
+		sprites = append(sprites, drawnSprite{sprite: sprite, matrix: matrix})
+	}
+	g.drawSprites(sprites)
+	if guided {
+		g.drawPanelMask(camera, panel)
 	}
+	g.drawCropEditor()
 
 	if g.infoDisplay {
 
@@ -299,11 +295,12 @@ func (g *GogoReader) Draw() {
 		infoText.Draw(g.win, pixel.IM.Scaled(infoText.Orig, textScale))
 	}
 
-	y := 0
-	for i := 0; i < len(g.messages); i++ {
-		//g.messages[i].Draw(screen, fontFace, 0, y)
-		y += 40
-	}
+	g.drawBookmarks()
+	g.drawScrubber()
+	g.drawDialog()
+	g.drawHelp()
+	g.drawAdjustments()
+	g.drawMessages()
 
 }
 
@@ -324,75 +321,103 @@ func (g *GogoReader) PreviousPage() bool {
 }
 
 func AppQuit(preferences Preferences) {
-	saveConfiguration(preferences)
+	if err := saveConfiguration(preferences); err != nil {
+		log.Printf("Unable to save configuration - %s\n", err.Error())
+	}
 	if *cpuprofile != "" {
 		pprof.StopCPUProfile()
 	}
 	os.Exit(0)
 }
 
-func backgroundColor(pictureData *pixel.PictureData, rect image.Rectangle) pixel.RGBA {
-	return gogoreader.ProminentColor(pictureData, rect)
+func backgroundColor(img image.Image, rect image.Rectangle) pixel.RGBA {
+	return gogoreader.ProminentImageColor(img, rect)
 }
 
-func (g *GogoReader) prepareView(viewData *ViewData) error {
+// prepareView reads the images of a view and creates their sprites, scaled down to the window size. When resampleLater
+// is true the images are scaled down in the background : the previous sprites are drawn until then, or the full images
+// if the frames of the view changed.
+func (g *GogoReader) prepareView(viewData *ViewData, resampleLater bool) error {
 
 	viewData.mu.Lock()
 	defer viewData.mu.Unlock()
 
-	if viewData.imageSprites != nil {
+	if viewData.imageSprites != nil && !viewData.outdated && viewData.preparedSize == g.prescaleSize() {
 		// page was already prepared
 		return nil
 	}
+	viewData.outdated = false
+	previousFrames := viewData.imageFrames
 
 	var err error
 	var totalWidth, h float64
-
-	viewData.BackgroundColors = make([]pixel.RGBA, 0, len(viewData.Images))
-	viewData.imageSprites = make([]*pixel.Sprite, 0, len(viewData.Images))
+	rawImages := make([]image.Image, 0, len(viewData.Images))
+	cropRects := make([]image.Rectangle, 0, len(viewData.Images))
+
+	// colors of the left and right sides of the view
+	viewData.BackgroundColors = make([]pixel.RGBA, 2)
+	leftIndex, rightIndex := 0, len(viewData.Images)-1
+	if album.RightToLeft {
+		leftIndex, rightIndex = rightIndex, leftIndex
+	}
+	viewData.imageFrames = make([]pixel.Rect, 0, len(viewData.Images))
+	viewData.imageBounds = make([]pixel.Rect, 0, len(viewData.Images))
+	viewData.cropRects = make([]image.Rectangle, 0, len(viewData.Images))
 	for index, imgData := range viewData.Images {
 		// ensure all images used by this page are loaded
 		var rawImage image.Image
-		rawImage, err = comicBook.ReadEntry(imgData.FileName)
+		rawImage, err = readImage(imgData)
 		if err != nil {
-			log.Printf("Error reading image %s - %s\n", imgData.FileName, err.Error())
-			return err
+			return fmt.Errorf("error reading image %s - %w", imgData.FileName, err)
 		}
-		if imgData.Rotation != None {
-			if imgData.Rotation == Left {
-				rawImage = imaging.Rotate90(rawImage)
-			} else if imgData.Rotation == Right {
-				rawImage = imaging.Rotate270(rawImage)
-			}
+		if index == 0 && album.AutoDeskew && !viewData.SkewDetected {
+			viewData.detectSkew(rawImage)
+		}
+		if album.AutoLevels != viewData.AutoLevelsOverride {
+			options := filters.DefaultLevelsOptions
+			options.NormalizePaper = album.PaperWhitening
+			rawImage = filters.AutoLevels(rawImage, options)
 		}
 		if album.GrayScale {
 			rawImage = imaging.Grayscale(rawImage)
 		}
-		if viewData.RotationAngle != 0 {
-			rawImage = imaging.Rotate(rawImage, viewData.RotationAngle, color.RGBA{255, 255, 255, 255})
+		rawImage = album.Adjustments.Add(viewData.Adjustments).Apply(rawImage)
+		rawImage = filters.ApplyColorVision(rawImage, g.preferences.ColorVision, g.preferences.ColorVisionSimulation)
+		// the corners uncovered by the rotation get the color of the paper
+		rawImage = deskew.Rotate(rawImage, viewData.angle())
+		// the theme is applied last so that the background colors, computed from the picture, match it
+		rawImage = album.Theme.Apply(rawImage)
+
+		// the crop rectangle is in image coordinates, from the top left corner
+		cropRect, consistentCrop := baseCrop(imgData, rawImage.Bounds())
+		if imgData.Left > 0 || imgData.Right > 0 || imgData.Bottom > 0 || imgData.Top > 0 {
+			cropRect = image.Rectangle{Min: image.Pt(cropRect.Min.X+imgData.Left, cropRect.Min.Y+imgData.Top), Max: image.Pt(cropRect.Max.X-imgData.Right, cropRect.Max.Y-imgData.Bottom)}
 		}
 
-		pictureData := pixel.PictureDataFromImage(rawImage)
-
-		cropRect := rawImage.Bounds()
-		if imgData.Left > 0 || imgData.Right > 0 || imgData.Bottom > 0 || imgData.Top > 0 {
-			cropRect = image.Rectangle{Min: image.Pt(cropRect.Min.X+imgData.Left, cropRect.Min.Y+imgData.Bottom), Max: image.Pt(cropRect.Max.X-imgData.Right, cropRect.Max.Y-imgData.Top)}
+		// the consistent crop of the album and the crop drawn by the user replace the border removal of each page
+		if !imgData.ManualCrop && ((viewData.bordersOverride && viewData.RemoveBorders) || (g.preferences.RemoveBorders && !consistentCrop)) {
+			crop.CropImageBorders(rawImage, &cropRect, album.CropOptions(g.preferences.Crop))
+		}
+		viewData.cropRects = append(viewData.cropRects, cropRect)
+		if viewData.editingCrop {
+			// the whole images are displayed while their crop is edited
+			cropRect = rawImage.Bounds()
 		}
 
-		if (viewData.bordersOverride && viewData.RemoveBorders) || g.preferences.RemoveBorders {
-			crop.CropBorders(pictureData, &cropRect)
+		if g.guided.active && imgData.Panels == nil {
+			imgData.Panels = detectPanels(rawImage, cropRect)
 		}
 
 		w := cropRect.Dx() / 5
 		offsetW := cropRect.Dx() / 20
 
-		if index == 0 {
+		if index == leftIndex {
 			rect := image.Rectangle{Min: image.Pt(cropRect.Min.X+offsetW, cropRect.Min.Y), Max: image.Pt(cropRect.Min.X+w, cropRect.Max.Y)}
-			viewData.BackgroundColors = append(viewData.BackgroundColors, backgroundColor(pictureData, rect))
+			viewData.BackgroundColors[0] = backgroundColor(rawImage, rect)
 		}
-		if index == len(viewData.Images)-1 {
+		if index == rightIndex {
 			rect := image.Rectangle{Min: image.Pt(cropRect.Max.X-w, cropRect.Min.Y), Max: image.Pt(cropRect.Max.X-offsetW, cropRect.Max.Y)}
-			viewData.BackgroundColors = append(viewData.BackgroundColors, backgroundColor(pictureData, rect))
+			viewData.BackgroundColors[1] = backgroundColor(rawImage, rect)
 		}
 
 		iw, ih := float64(cropRect.Dx()), float64(cropRect.Dy())
@@ -401,8 +426,44 @@ func (g *GogoReader) prepareView(viewData *ViewData) error {
 			h = ih
 		}
 
-		sprite := pixel.NewSprite(pictureData, pixel.R(float64(cropRect.Min.X), float64(cropRect.Min.Y), float64(cropRect.Max.X), float64(cropRect.Max.Y)))
-		viewData.imageSprites = append(viewData.imageSprites, sprite)
+		rawImages = append(rawImages, rawImage)
+		cropRects = append(cropRects, cropRect)
+		// pictures are upside down : their coordinates start from the bottom left corner
+		bounds := rawImage.Bounds()
+		frame := pictureToImage(bounds, cropRect)
+		viewData.imageFrames = append(viewData.imageFrames, pixel.R(float64(frame.Min.X), float64(frame.Min.Y), float64(frame.Max.X), float64(frame.Max.Y)))
+		viewData.imageBounds = append(viewData.imageBounds, pixel.R(float64(bounds.Min.X), float64(bounds.Min.Y), float64(bounds.Max.X), float64(bounds.Max.Y)))
+	}
+
+	// the images are scaled down to the size they are displayed at with the filter of the preferences
+	viewData.preparedSize = g.prescaleSize()
+	scale := prescale(viewData.preparedSize, totalWidth, h)
+	// the images scaled down in the background for a previous preparation are dropped
+	viewData.generation++
+	if scale < 1 && resampleLater {
+		if viewData.imageSprites == nil || !sameFrames(previousFrames, viewData.imageFrames) {
+			viewData.imageSprites = make([]*pixel.Sprite, 0, len(viewData.Images))
+			viewData.spriteScales = make([]float64, 0, len(viewData.Images))
+			for index, frame := range viewData.imageFrames {
+				viewData.imageSprites = append(viewData.imageSprites, pixel.NewSprite(pixel.PictureDataFromImage(rawImages[index]), frame))
+				viewData.spriteScales = append(viewData.spriteScales, 1)
+			}
+		}
+		g.resampleLater(viewData, rawImages, cropRects, scale)
+		viewData.updateSize()
+		return nil
+	}
+	viewData.imageSprites = make([]*pixel.Sprite, 0, len(viewData.Images))
+	viewData.spriteScales = make([]float64, 0, len(viewData.Images))
+	for index, frame := range viewData.imageFrames {
+		if scale < 1 {
+			pictureData := resampleImage(rawImages[index], cropRects[index], scale, g.preferences.Filter.ResampleFilter())
+			viewData.imageSprites = append(viewData.imageSprites, pixel.NewSprite(pictureData, pictureData.Bounds()))
+			viewData.spriteScales = append(viewData.spriteScales, pictureData.Bounds().W()/frame.W())
+		} else {
+			viewData.imageSprites = append(viewData.imageSprites, pixel.NewSprite(pixel.PictureDataFromImage(rawImages[index]), frame))
+			viewData.spriteScales = append(viewData.spriteScales, 1)
+		}
 	}
 
 	viewData.updateSize()
@@ -448,6 +509,7 @@ func run() {
 	icons = append(icons, pixel.PictureDataFromImage(image))
 
 	g := &GogoReader{}
+	g.scrubber.Height = 16
 
 	log.Printf("Loading %s\n", archiveFile)
 	comicBook, err = files.FromFile(archiveFile)
@@ -470,6 +532,10 @@ func run() {
 		if err != nil {
 			g.fatalErr = err
 		}
+		album.FileName, _ = filepath.Abs(archiveFile)
+		thumbnailCache := newThumbnailCache(g.preferences)
+		go thumbnailCache.Cleanup()
+		g.thumbnails = newThumbnailLoader(thumbnailCache)
 	}
 
 	g.needsRefresh = true
@@ -506,12 +572,19 @@ func run() {
 		}
 
 	} else {
+		g.keyMap = NewKeyMap(actions, g.preferences.KeyBindings)
+		for _, warning := range append(g.keyMap.Warnings, checkClickZones(g.preferences.ClickZones)...) {
+			g.notifyError(errors.New(warning))
+		}
+
 		g.win.SetSmooth(true)
 		g.ToggleFullScreen()
 		g.refresh()
 
 		for !g.win.Closed() {
-			g.Update()
+			if err := g.Update(); err != nil {
+				g.notifyError(err)
+			}
 			g.Draw()
 			g.win.Update()
 		}
@@ -534,6 +607,14 @@ func main() {
 
 	log.Println(flag.Args())
 
+	if command, found := commands[flag.Arg(0)]; found {
+		if err := command(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -550,6 +631,7 @@ func (g *GogoReader) goTo(newImageIndex int) error {
 	if newImageIndex == album.CurrentViewIndex {
 		return nil
 	}
+	g.startTransition(newImageIndex > album.CurrentViewIndex)
 	album.CurrentViewIndex = newImageIndex
 	g.needsRefresh = true
 	return nil
@@ -557,18 +639,24 @@ func (g *GogoReader) goTo(newImageIndex int) error {
 
 func (g *GogoReader) refresh() error {
 
+	g.updateResampled()
 	if !g.needsRefresh {
 		return nil
 	}
 	g.needsRefresh = false
-	album.GetCurrentView().imageSprites = nil
-	err := g.prepareView(album.GetCurrentView())
+	// the current sprites are drawn until the view is scaled down again
+	album.GetCurrentView().outdated = true
+	err := g.prepareView(album.GetCurrentView(), true)
 	if err != nil {
 		return err
 	}
 	if album.CurrentViewIndex < len(album.Views)-1 {
 		// prepare next page in the background
-		go g.prepareView(album.Views[album.CurrentViewIndex+1])
+		go func(view *ViewData) {
+			if err := g.prepareView(view, false); err != nil {
+				g.notifyError(err)
+			}
+		}(album.Views[album.CurrentViewIndex+1])
 	}
 
 	return err
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/mozvip/gomics/thumbnails"
+)
+
+const gridPadding = 16.0
+
+// thumbnailLoader creates the thumbnails of the album images in the background,
+// going through the on-disk thumbnail cache
+type thumbnailLoader struct {
+	mu      sync.Mutex
+	cache   *thumbnails.Cache
+	sprites map[string]*pixel.Sprite
+	queued  map[string]bool
+	queue   chan ImageData
+}
+
+func newThumbnailLoader(cache *thumbnails.Cache) *thumbnailLoader {
+	loader := &thumbnailLoader{
+		cache:   cache,
+		sprites: make(map[string]*pixel.Sprite),
+		queued:  make(map[string]bool),
+		queue:   make(chan ImageData, 1024),
+	}
+	go loader.run()
+	return loader
+}
+
+// Sprite returns the thumbnail for the given image, or nil if it is not ready yet
+func (t *thumbnailLoader) Sprite(imgData *ImageData) *pixel.Sprite {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := thumbnailKey(imgData)
+	if sprite, hasKey := t.sprites[key]; hasKey {
+		return sprite
+	}
+	if !t.queued[key] {
+		select {
+		// the image is copied, the loader must not read the images edited by the main loop
+		case t.queue <- *imgData:
+			t.queued[key] = true
+		default:
+			// queue is full, we will try again on the next frame
+		}
+	}
+	return nil
+}
+
+func (t *thumbnailLoader) run() {
+	for imgData := range t.queue {
+		key := thumbnailKey(&imgData)
+		img, err := t.cache.GetOrCreate(thumbnails.EntryURI(album.MD5, key), 0, thumbnails.Normal, func() (image.Image, error) {
+			// the whole album would stay in memory at full size if the entries were cached
+			return decodePage(&imgData, comicBook.ReadEntryUncached)
+		})
+		if err != nil {
+			log.Printf("Unable to create thumbnail for %s - %s\n", key, err.Error())
+			continue
+		}
+		pictureData := pixel.PictureDataFromImage(img)
+		t.mu.Lock()
+		t.sprites[key] = pixel.NewSprite(pictureData, pictureData.Bounds())
+		t.mu.Unlock()
+	}
+}
+
+// thumbnailKey identifies the thumbnail of an image, the halves of a spread changing with the rotation of the spread
+func thumbnailKey(imgData *ImageData) string {
+	if imgData.Half == HalfNone {
+		return imgData.key()
+	}
+	return fmt.Sprintf("%s@%d", imgData.key(), imgData.SpreadRotation)
+}
+
+func (g *GogoReader) gridCellSize() pixel.Vec {
+	return pixel.V(float64(thumbnails.Normal)+gridPadding, float64(thumbnails.Normal)+gridPadding+fontAtlas.LineHeight())
+}
+
+func (g *GogoReader) gridColumns() int {
+	return int(math.Max(1, math.Floor(g.size.X/g.gridCellSize().X)))
+}
+
+// gridCell returns the rectangle of the cell used to display the given view
+func (g *GogoReader) gridCell(viewIndex int) pixel.Rect {
+	cell := g.gridCellSize()
+	columns := g.gridColumns()
+	marginX := (g.size.X - float64(columns)*cell.X) / 2
+	x := marginX + float64(viewIndex%columns)*cell.X
+	y := g.size.Y - float64(viewIndex/columns+1)*cell.Y + g.gridScroll
+	return pixel.R(x, y, x+cell.X, y+cell.Y)
+}
+
+// gridItem is a cell of the thumbnails : a page of the album, or a hidden image
+type gridItem struct {
+	image   *ImageData
+	label   string
+	flag    PageFlag
+	current bool
+}
+
+// gridItems returns the cells of the thumbnails : the pages of the album, or the hidden images when restoring pages
+func (g *GogoReader) gridItems() []gridItem {
+	var items []gridItem
+	if g.gridHidden {
+		for _, img := range album.hiddenImages() {
+			items = append(items, gridItem{image: img, label: fmt.Sprintf("#%d", album.ImageIndex(img)+1), flag: img.Flag})
+		}
+		return items
+	}
+	for index, view := range album.Views {
+		items = append(items, gridItem{image: view.Images[0], label: fmt.Sprintf("%d", index+1), flag: view.flag(), current: index == album.CurrentViewIndex})
+	}
+	return items
+}
+
+// toggleHiddenDisplay shows the thumbnails of the hidden images, clicking on an image restores it
+func (g *GogoReader) toggleHiddenDisplay() {
+	if len(album.hiddenImages()) == 0 {
+		g.notify("No hidden page")
+		return
+	}
+	g.gridDisplay = true
+	g.gridHidden = true
+	g.gridScroll = 0
+	g.gridSelection = 0
+	g.notify("Click on a page to restore it")
+}
+
+func (g *GogoReader) toggleGridDisplay() {
+	g.gridDisplay = !g.gridDisplay
+	g.gridHidden = false
+	if g.gridDisplay {
+		g.gridSelection = album.CurrentViewIndex
+		// scroll so that the current page is visible
+		cell := g.gridCellSize()
+		row := album.CurrentViewIndex / g.gridColumns()
+		g.gridScroll = math.Max(0, float64(row)*cell.Y-g.size.Y/2+cell.Y/2)
+	}
+}
+
+func (g *GogoReader) updateGrid() {
+	items := g.gridItems()
+	cell := g.gridCellSize()
+	rows := (len(items) + g.gridColumns() - 1) / g.gridColumns()
+	maxScroll := math.Max(0, float64(rows)*cell.Y-g.size.Y)
+
+	g.gridScroll -= g.win.MouseScroll().Y * cell.Y / 2
+	if g.win.Repeated(pixelgl.KeyPageDown) || g.win.JustPressed(pixelgl.KeyPageDown) {
+		g.gridScroll += g.size.Y
+	}
+	if g.win.Repeated(pixelgl.KeyPageUp) || g.win.JustPressed(pixelgl.KeyPageUp) {
+		g.gridScroll -= g.size.Y
+	}
+	if g.win.JustPressed(pixelgl.KeyHome) {
+		g.gridScroll = 0
+	}
+	if g.win.JustPressed(pixelgl.KeyEnd) {
+		g.gridScroll = maxScroll
+	}
+	// the selection is moved with the arrows or the directional pad, and kept visible
+	pressed := func(key pixelgl.Button, button pixelgl.GamepadButton) bool {
+		return g.win.JustPressed(key) || g.win.Repeated(key) || g.gamepadJustPressed(button)
+	}
+	selection := g.gridSelection
+	if pressed(pixelgl.KeyLeft, pixelgl.ButtonDpadLeft) {
+		selection--
+	}
+	if pressed(pixelgl.KeyRight, pixelgl.ButtonDpadRight) {
+		selection++
+	}
+	if pressed(pixelgl.KeyUp, pixelgl.ButtonDpadUp) {
+		selection -= g.gridColumns()
+	}
+	if pressed(pixelgl.KeyDown, pixelgl.ButtonDpadDown) {
+		selection += g.gridColumns()
+	}
+	selection = int(math.Max(0, math.Min(float64(selection), float64(len(items)-1))))
+	if selection != g.gridSelection {
+		g.gridSelection = selection
+		selected := g.gridCell(selection)
+		if selected.Max.Y > g.size.Y {
+			g.gridScroll -= selected.Max.Y - g.size.Y
+		} else if selected.Min.Y < 0 {
+			g.gridScroll -= selected.Min.Y
+		}
+	}
+	g.gridScroll = math.Max(0, math.Min(g.gridScroll, maxScroll))
+
+	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
+		for index := range items {
+			if g.gridCell(index).Contains(g.win.MousePosition()) {
+				g.openGridItem(items[index], index)
+				return
+			}
+		}
+	}
+	if (g.win.JustPressed(pixelgl.KeyEnter) || g.win.JustPressed(pixelgl.KeyKPEnter) || g.gamepadJustPressed(pixelgl.ButtonA)) && g.gridSelection < len(items) {
+		g.openGridItem(items[g.gridSelection], g.gridSelection)
+		return
+	}
+
+	if g.win.JustPressed(pixelgl.KeyEscape) || g.gamepadJustPressed(pixelgl.ButtonB) || g.actionTriggered("thumbnails") {
+		g.gridDisplay = false
+	}
+}
+
+// openGridItem goes to the page of a thumbnail, or restores a hidden image
+func (g *GogoReader) openGridItem(item gridItem, index int) {
+	if g.gridHidden {
+		g.record("restore-page", false, func() { g.restorePage(item.image) })
+		if len(album.hiddenImages()) == 0 {
+			g.gridDisplay = false
+		}
+		g.gridSelection = int(math.Max(0, math.Min(float64(index), float64(len(album.hiddenImages())-1))))
+		return
+	}
+	g.goTo(index)
+	g.gridDisplay = false
+}
+
+func (g *GogoReader) drawGrid() {
+	g.win.Clear(color.RGBA{20, 20, 20, 255})
+
+	imd := imdraw.New(nil)
+	labels := text.New(pixel.ZV, fontAtlas)
+	for index, item := range g.gridItems() {
+		cell := g.gridCell(index)
+		if cell.Max.Y < 0 || cell.Min.Y > g.size.Y {
+			continue
+		}
+
+		if index == g.gridSelection {
+			imd.Color = color.RGBA{90, 140, 200, 255}
+			imd.Push(cell.Min, cell.Max)
+			imd.Rectangle(0)
+		}
+		if item.current {
+			imd.Color = color.RGBA{200, 200, 200, 255}
+			imd.Push(cell.Min.Add(pixel.V(2, 2)), cell.Max.Sub(pixel.V(2, 2)))
+			imd.Rectangle(2)
+		}
+
+		label := item.label
+		if item.flag != FlagNone {
+			label = fmt.Sprintf("%s %s", item.label, item.flag)
+			imd.Color = color.RGBA{230, 140, 40, 255}
+			imd.Push(cell.Min.Add(pixel.V(5, 5)), cell.Max.Sub(pixel.V(5, 5)))
+			imd.Rectangle(2)
+		}
+		labels.Dot = pixel.V(cell.Center().X-labels.BoundsOf(label).W()/2, cell.Min.Y+gridPadding/2)
+		fmt.Fprint(labels, label)
+
+		sprite := g.thumbnails.Sprite(item.image)
+		if sprite != nil {
+			center := pixel.V(cell.Center().X, cell.Min.Y+fontAtlas.LineHeight()+gridPadding/2+float64(thumbnails.Normal)/2)
+			sprite.Draw(g.win, pixel.IM.Moved(center))
+		}
+	}
+	imd.Draw(g.win)
+	labels.Draw(g.win, pixel.IM)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/mozvip/gomics/panels"
+)
+
+const (
+	// duration of the move from a panel to the next one
+	guidedMoveDuration = 0.35
+	// part of the window used by a panel
+	guidedMargin = 0.95
+)
+
+// camera is the part of the view displayed in the window : the point of the view at the center of the window, and the scale
+type camera struct {
+	center pixel.Vec
+	scale  float64
+}
+
+// guidedView displays the pages panel by panel
+type guidedView struct {
+	active bool
+	// viewIndex is the view the panel index belongs to
+	viewIndex int
+	// panel is the index of the displayed panel among the panels of the view, -1 for the last panel
+	panel int
+
+	camera camera
+	from   camera
+	to     camera
+	start  time.Time
+
+	// positions of the sprites of the current view when it was last drawn
+	positions []pixel.Vec
+	// drawing is true while a new panel is drawn with the mouse
+	drawing bool
+}
+
+// panelRef is a panel of one of the images of a view
+type panelRef struct {
+	imageIndex int
+	panel      Panel
+}
+
+// viewPanels returns the panels of a view, in reading order
+func viewPanels(view *ViewData) []panelRef {
+	var refs []panelRef
+	for index, img := range view.Images {
+		for _, panel := range img.Panels {
+			refs = append(refs, panelRef{imageIndex: index, panel: panel})
+		}
+	}
+	return refs
+}
+
+// detectPanels returns the panels of the rect part of an image
+func detectPanels(img image.Image, rect image.Rectangle) []Panel {
+	options := panels.DefaultOptions
+	options.RightToLeft = album.RightToLeft
+	var detected []Panel
+	for _, r := range panels.Detect(img, rect, options) {
+		detected = append(detected, panelFromRect(r))
+	}
+	return detected
+}
+
+// panelLayout returns the rectangle of a panel of the current view, before the view is scaled to the window
+func (g *GogoReader) panelLayout(view *ViewData, ref panelRef) (pixel.Rect, bool) {
+	if ref.imageIndex >= len(view.imageFrames) || ref.imageIndex >= len(g.guided.positions) {
+		return pixel.Rect{}, false
+	}
+	frame, bounds := view.imageFrames[ref.imageIndex], view.imageBounds[ref.imageIndex]
+	imageBounds := image.Rect(int(bounds.Min.X), int(bounds.Min.Y), int(bounds.Max.X), int(bounds.Max.Y))
+	r := pictureToImage(imageBounds, ref.panel.Rect())
+	rect := pixel.R(float64(r.Min.X), float64(r.Min.Y), float64(r.Max.X), float64(r.Max.Y)).Intersect(frame)
+	if rect.Area() == 0 {
+		return pixel.Rect{}, false
+	}
+	return rect.Moved(g.guided.positions[ref.imageIndex].Sub(frame.Center())), true
+}
+
+// guidedCamera returns the camera displaying the current panel, ok is false when the view has no panel
+func (g *GogoReader) guidedCamera(view *ViewData, positions []pixel.Vec) (camera, pixel.Rect, bool) {
+	g.guided.positions = positions
+	if !g.guided.active {
+		return camera{}, pixel.Rect{}, false
+	}
+	if g.guided.viewIndex != album.CurrentViewIndex {
+		// the page was changed without the panel navigation
+		g.guided.viewIndex = album.CurrentViewIndex
+		g.guided.panel = 0
+	}
+	refs := viewPanels(view)
+	if len(refs) == 0 {
+		return camera{}, pixel.Rect{}, false
+	}
+	if g.guided.panel < 0 || g.guided.panel >= len(refs) {
+		g.guided.panel = len(refs) - 1
+	}
+	rect, ok := g.panelLayout(view, refs[g.guided.panel])
+	if !ok {
+		return camera{}, pixel.Rect{}, false
+	}
+
+	target := camera{center: rect.Center(), scale: guidedMargin * math.Min(g.size.X/rect.W(), g.size.Y/rect.H())}
+	if target != g.guided.to {
+		g.guided.from = g.guided.camera
+		if g.guided.from.scale == 0 {
+			// start from the whole view
+			g.guided.from = camera{center: g.win.Bounds().Center(), scale: g.size.Y / view.maxHeight}
+		}
+		g.guided.to = target
+		g.guided.start = time.Now()
+	}
+	progress := math.Min(1, time.Since(g.guided.start).Seconds()/guidedMoveDuration)
+	eased := (1 - math.Cos(progress*math.Pi)) / 2
+	g.guided.camera = camera{
+		center: pixel.Lerp(g.guided.from.center, g.guided.to.center, eased),
+		scale:  g.guided.from.scale + (g.guided.to.scale-g.guided.from.scale)*eased,
+	}
+	return g.guided.camera, rect, true
+}
+
+// matrix returns the matrix drawing the sprite at the given position of the view through the camera
+func (c camera) matrix(win *pixelgl.Window, position pixel.Vec) pixel.Matrix {
+	center := win.Bounds().Center()
+	return pixel.IM.Moved(position).Moved(center.Sub(c.center)).Scaled(center, c.scale)
+}
+
+// toScreen converts a point of the view to a point of the window
+func (c camera) toScreen(win *pixelgl.Window, position pixel.Vec) pixel.Vec {
+	center := win.Bounds().Center()
+	return center.Add(position.Sub(c.center).Scaled(c.scale))
+}
+
+// toView converts a point of the window to a point of the view
+func (c camera) toView(win *pixelgl.Window, position pixel.Vec) pixel.Vec {
+	center := win.Bounds().Center()
+	return c.center.Add(position.Sub(center).Scaled(1 / c.scale))
+}
+
+func (g *GogoReader) toggleGuidedView() {
+	g.guided = guidedView{active: !g.guided.active, viewIndex: album.CurrentViewIndex}
+	if g.guided.active {
+		g.Zoom = false
+		g.notify("Guided view : use the next / previous page keys to move from panel to panel")
+	} else {
+		g.notify("Guided view off")
+	}
+	// the panels are detected when the view is prepared
+	g.needsRefresh = true
+}
+
+func (g *GogoReader) nextPanel() {
+	if g.guided.panel >= 0 && g.guided.panel < len(viewPanels(album.GetCurrentView()))-1 {
+		g.guided.panel++
+		return
+	}
+	if album.CurrentViewIndex < len(album.Views)-1 {
+		g.NextPage()
+		g.guided.viewIndex = album.CurrentViewIndex
+		g.guided.panel = 0
+	}
+}
+
+func (g *GogoReader) previousPanel() {
+	if g.guided.panel > 0 {
+		g.guided.panel--
+		return
+	}
+	if album.CurrentViewIndex > 0 {
+		g.PreviousPage()
+		g.guided.viewIndex = album.CurrentViewIndex
+		g.guided.panel = -1
+	}
+}
+
+// currentPanel returns the image displaying the current panel and the index of the panel among the panels of the image
+func (g *GogoReader) currentPanel() (*ImageData, int, bool) {
+	if !g.guided.active {
+		g.notify("Panels can only be edited in the guided view")
+		return nil, 0, false
+	}
+	view := album.GetCurrentView()
+	panel := g.guided.panel
+	for _, img := range view.Images {
+		if panel < len(img.Panels) {
+			return img, panel, panel >= 0
+		}
+		panel -= len(img.Panels)
+	}
+	return nil, 0, false
+}
+
+// mergePanel merges the current panel with the next panel of the same image
+func (g *GogoReader) mergePanel() {
+	img, index, ok := g.currentPanel()
+	if !ok {
+		return
+	}
+	if index >= len(img.Panels)-1 {
+		g.notify("No panel to merge with")
+		return
+	}
+	img.Panels[index] = panelFromRect(img.Panels[index].Rect().Union(img.Panels[index+1].Rect()))
+	img.Panels = append(img.Panels[:index+1], img.Panels[index+2:]...)
+	img.PanelsEdited = true
+	g.notify("Panels merged")
+}
+
+// deletePanel removes the current panel, the last panel of an image is kept
+func (g *GogoReader) deletePanel() {
+	img, index, ok := g.currentPanel()
+	if !ok {
+		return
+	}
+	if len(img.Panels) == 1 {
+		g.notify("The last panel of an image can not be removed")
+		return
+	}
+	img.Panels = append(img.Panels[:index], img.Panels[index+1:]...)
+	img.PanelsEdited = true
+	if g.guided.panel >= len(viewPanels(album.GetCurrentView())) {
+		g.guided.panel--
+	}
+	g.notify("Panel removed")
+}
+
+// detectViewPanels detects the panels of the current view again, removing the manual corrections
+func (g *GogoReader) detectViewPanels() {
+	album.GetCurrentView().ClearPanels()
+	g.guided.panel = 0
+	g.needsRefresh = true
+	g.notify("Panels detected again")
+}
+
+// addPanel adds the panel drawn with the mouse between two points of the window, after the current panel
+func (g *GogoReader) addPanel(from, to pixel.Vec) {
+	view := album.GetCurrentView()
+	a, b := g.guided.camera.toView(g.win, from), g.guided.camera.toView(g.win, to)
+	drawn := pixel.R(math.Min(a.X, b.X), math.Min(a.Y, b.Y), math.Max(a.X, b.X), math.Max(a.Y, b.Y))
+
+	for imageIndex, frame := range view.imageFrames {
+		if imageIndex >= len(g.guided.positions) {
+			break
+		}
+		layout := frame.Moved(g.guided.positions[imageIndex].Sub(frame.Center()))
+		if !layout.Contains(drawn.Center()) {
+			continue
+		}
+		rect := drawn.Intersect(layout).Moved(frame.Center().Sub(g.guided.positions[imageIndex]))
+		if rect.W() < 1 || rect.H() < 1 {
+			return
+		}
+		bounds := view.imageBounds[imageIndex]
+		imageBounds := image.Rect(int(bounds.Min.X), int(bounds.Min.Y), int(bounds.Max.X), int(bounds.Max.Y))
+		r := pictureToImage(imageBounds, image.Rect(int(rect.Min.X), int(rect.Min.Y), int(rect.Max.X), int(rect.Max.Y)))
+		panel := panelFromRect(r)
+
+		// insert the panel after the current one, or at the end of the panels of its image
+		img := view.Images[imageIndex]
+		position := len(img.Panels)
+		if current, index, ok := g.currentPanel(); ok && current == img {
+			position = index + 1
+		}
+		img.Panels = append(img.Panels[:position], append([]Panel{panel}, img.Panels[position:]...)...)
+		img.PanelsEdited = true
+		for _, ref := range view.Images[:imageIndex] {
+			position += len(ref.Panels)
+		}
+		g.guided.panel = position
+		g.notify("Panel added")
+		return
+	}
+}
+
+// drawPanelMask darkens the parts of the window around the current panel, and draws the panel being added
+func (g *GogoReader) drawPanelMask(c camera, panel pixel.Rect) {
+	low, high := c.toScreen(g.win, panel.Min), c.toScreen(g.win, panel.Max)
+	imd := imdraw.New(nil)
+	imd.Color = color.RGBA{0, 0, 0, 170}
+	for _, r := range []pixel.Rect{
+		pixel.R(0, 0, g.size.X, low.Y),
+		pixel.R(0, high.Y, g.size.X, g.size.Y),
+		pixel.R(0, low.Y, low.X, high.Y),
+		pixel.R(high.X, low.Y, g.size.X, high.Y),
+	} {
+		if r.W() > 0 && r.H() > 0 {
+			imd.Push(r.Min, r.Max)
+			imd.Rectangle(0)
+		}
+	}
+	if g.guided.drawing {
+		imd.Color = color.RGBA{90, 140, 200, 255}
+		imd.Push(g.dragStart, g.win.MousePosition())
+		imd.Rectangle(2)
+	}
+	imd.Draw(g.win)
+}
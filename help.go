@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+)
+
+const helpTextScale = 2.0
+
+var helpCategoryColor = color.RGBA{120, 170, 230, 255}
+
+func (g *GogoReader) toggleHelpDisplay() {
+	g.helpDisplay = !g.helpDisplay
+	g.helpScroll = 0
+}
+
+// helpLines returns the lines of the help, built from the current key map
+func (g *GogoReader) helpLines() []string {
+	lines := g.keyMap.Help(actions)
+
+	lines = append(lines, "", "Mouse")
+	zones := g.preferences.ClickZones
+	if zones.Enabled {
+		lines = append(lines,
+			fmt.Sprintf("  %-28s %s", "Click on the left side", g.clickZoneAction(0)),
+			fmt.Sprintf("  %-28s %s", "Click in the center", zones.Center),
+			fmt.Sprintf("  %-28s %s", "Click on the right side", g.clickZoneAction(g.size.X)))
+	} else {
+		lines = append(lines, fmt.Sprintf("  %-28s %s", "Click", zones.Center))
+	}
+	if g.preferences.SwipeDistance > 0 {
+		lines = append(lines, fmt.Sprintf("  %-28s %s", "Drag left / right", "Turn the page"))
+	}
+
+	return append(lines, "", "Up / Down / mouse wheel to scroll, Escape to close")
+}
+
+func (g *GogoReader) updateHelp() {
+	lineHeight := fontAtlas.LineHeight() * helpTextScale
+	maxScroll := math.Max(0, float64(len(g.helpLines())+2)*lineHeight-g.size.Y)
+
+	g.helpScroll -= g.win.MouseScroll().Y * lineHeight * 3
+	if g.win.JustPressed(pixelgl.KeyDown) || g.win.Repeated(pixelgl.KeyDown) || g.gamepadJustPressed(pixelgl.ButtonDpadDown) {
+		g.helpScroll += lineHeight
+	}
+	if g.win.JustPressed(pixelgl.KeyUp) || g.win.Repeated(pixelgl.KeyUp) || g.gamepadJustPressed(pixelgl.ButtonDpadUp) {
+		g.helpScroll -= lineHeight
+	}
+	if g.win.JustPressed(pixelgl.KeyPageDown) {
+		g.helpScroll += g.size.Y / 2
+	}
+	if g.win.JustPressed(pixelgl.KeyPageUp) {
+		g.helpScroll -= g.size.Y / 2
+	}
+	g.helpScroll = math.Max(0, math.Min(g.helpScroll, maxScroll))
+
+	if g.win.JustPressed(pixelgl.KeyEscape) || g.gamepadJustPressed(pixelgl.ButtonB) {
+		g.helpDisplay = false
+	}
+	if g.actionTriggered("help") {
+		g.helpDisplay = false
+	}
+}
+
+func (g *GogoReader) drawHelp() {
+	if !g.helpDisplay {
+		return
+	}
+
+	imd := imdraw.New(nil)
+	imd.Color = color.RGBA{20, 20, 20, 230}
+	imd.Push(pixel.ZV, g.size)
+	imd.Rectangle(0)
+	imd.Draw(g.win)
+
+	lineHeight := fontAtlas.LineHeight() * helpTextScale
+	helpText := text.New(pixel.ZV, fontAtlas)
+	for _, line := range g.helpLines() {
+		if line != "" && !strings.HasPrefix(line, " ") {
+			// category title
+			helpText.Color = helpCategoryColor
+		} else {
+			helpText.Color = color.White
+		}
+		fmt.Fprintln(helpText, line)
+	}
+
+	top := g.size.Y - lineHeight + g.helpScroll
+	helpText.Draw(g.win, pixel.IM.Scaled(pixel.ZV, helpTextScale).Moved(pixel.V(lineHeight, top)))
+}
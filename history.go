@@ -0,0 +1,418 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"reflect"
+
+	"github.com/mozvip/gomics/crop"
+	"github.com/mozvip/gomics/filters"
+	"gopkg.in/yaml.v3"
+)
+
+// imageState is the part of an image edited by the user, the panels being kept once corrected by the user only
+type imageState struct {
+	FileName       string
+	Half           Half `yaml:",omitempty"`
+	Visible        bool
+	Rotation       Rotation `yaml:",omitempty"`
+	SpreadRotation Rotation `yaml:",omitempty"`
+	Top            int      `yaml:",omitempty"`
+	Bottom         int      `yaml:",omitempty"`
+	Left           int      `yaml:",omitempty"`
+	Right          int      `yaml:",omitempty"`
+	ManualCrop     bool     `yaml:",omitempty"`
+	Gutter         float64  `yaml:",omitempty"`
+	Overlap        float64  `yaml:",omitempty"`
+	Flag           PageFlag `yaml:",omitempty"`
+	Panels         []Panel  `yaml:",omitempty"`
+}
+
+func newImageState(img *ImageData) imageState {
+	state := imageState{
+		FileName: img.FileName, Half: img.Half, Visible: img.Visible, Rotation: img.Rotation, SpreadRotation: img.SpreadRotation,
+		Top: img.Top, Bottom: img.Bottom, Left: img.Left, Right: img.Right, ManualCrop: img.ManualCrop,
+		Gutter: img.Gutter, Overlap: img.Overlap, Flag: img.Flag,
+	}
+	if img.PanelsEdited {
+		// the panels are edited in place
+		state.Panels = append([]Panel(nil), img.Panels...)
+	}
+	return state
+}
+
+func (s imageState) key() string {
+	img := ImageData{FileName: s.FileName, Half: s.Half}
+	return img.key()
+}
+
+// apply restores the image, its panels are detected again unless they were corrected by the user
+func (s imageState) apply(img *ImageData) {
+	img.FileName, img.Half, img.Visible, img.Rotation, img.SpreadRotation = s.FileName, s.Half, s.Visible, s.Rotation, s.SpreadRotation
+	img.Top, img.Bottom, img.Left, img.Right, img.ManualCrop = s.Top, s.Bottom, s.Left, s.Right, s.ManualCrop
+	img.Gutter, img.Overlap, img.Flag = s.Gutter, s.Overlap, s.Flag
+	if s.Panels != nil {
+		img.Panels, img.PanelsEdited = append([]Panel(nil), s.Panels...), true
+	} else if img.PanelsEdited {
+		img.Panels, img.PanelsEdited = nil, false
+	}
+}
+
+// viewState is the part of a view edited by the user, its images being indexes in the images of the album
+type viewState struct {
+	Images             []int
+	RotationAngle      float64     `yaml:",omitempty"`
+	AngleSet           bool        `yaml:",omitempty"`
+	RemoveBorders      bool        `yaml:",omitempty"`
+	Adjustments        Adjustments `yaml:",omitempty"`
+	AutoLevelsOverride bool        `yaml:",omitempty"`
+}
+
+// albumSettings are the settings of the whole album edited by the user
+type albumSettings struct {
+	GrayScale      bool
+	AutoLevels     bool
+	PaperWhitening bool
+	AutoDeskew     bool
+	Theme          filters.Theme `yaml:",omitempty"`
+	Adjustments    Adjustments   `yaml:",omitempty"`
+	RemoveBorders  bool
+	Crop           *crop.Options `yaml:",omitempty"`
+	ConsistentCrop *AlbumCrop    `yaml:",omitempty"`
+	RightToLeft    bool
+}
+
+// albumState is the part of the album edited by the user. The settings computed by the reader (skew detection,
+// background colors, detected panels) are left out. Once recorded, an edit only keeps the images and the views
+// it changed, by index.
+type albumState struct {
+	ImageCount int
+	Images     map[int]imageState `yaml:",omitempty"`
+	ViewCount  int
+	Views      map[int]viewState `yaml:",omitempty"`
+	Settings   albumSettings
+}
+
+// currentState returns the state of the album, each view being locked as the prefetching goroutine may be preparing it
+func currentState() albumState {
+	state := albumState{
+		ImageCount: len(album.Images),
+		Images:     make(map[int]imageState, len(album.Images)),
+		ViewCount:  len(album.Views),
+		Views:      make(map[int]viewState, len(album.Views)),
+		Settings: albumSettings{
+			GrayScale: album.GrayScale, AutoLevels: album.AutoLevels, PaperWhitening: album.PaperWhitening,
+			AutoDeskew: album.AutoDeskew, Theme: album.Theme, Adjustments: album.Adjustments,
+			RemoveBorders: album.RemoveBorders, ConsistentCrop: album.ConsistentCrop, RightToLeft: album.RightToLeft,
+		},
+	}
+	if album.Crop != nil {
+		options := *album.Crop
+		state.Settings.Crop = &options
+	}
+
+	indexes := make(map[string]int, len(album.Images))
+	for index, img := range album.Images {
+		indexes[img.key()] = index
+	}
+	for viewIndex, view := range album.Views {
+		view.mu.Lock()
+		viewState := viewState{
+			Images:        make([]int, 0, len(view.Images)),
+			RotationAngle: view.RotationAngle, AngleSet: view.AngleSet, RemoveBorders: view.RemoveBorders,
+			Adjustments: view.Adjustments, AutoLevelsOverride: view.AutoLevelsOverride,
+		}
+		for _, img := range view.Images {
+			if index, found := indexes[img.key()]; found {
+				viewState.Images = append(viewState.Images, index)
+				state.Images[index] = newImageState(img)
+			}
+		}
+		view.mu.Unlock()
+		state.Views[viewIndex] = viewState
+	}
+	for index, img := range album.Images {
+		if _, found := state.Images[index]; !found {
+			state.Images[index] = newImageState(img)
+		}
+	}
+	return state
+}
+
+// diffStates removes the images and the views which are the same before and after an edit, they are kept
+// when their number changed
+func diffStates(before, after *albumState) {
+	if before.ImageCount == after.ImageCount {
+		for index, state := range before.Images {
+			if reflect.DeepEqual(state, after.Images[index]) {
+				delete(before.Images, index)
+				delete(after.Images, index)
+			}
+		}
+	}
+	if before.ViewCount == after.ViewCount {
+		for index, state := range before.Views {
+			if reflect.DeepEqual(state, after.Views[index]) {
+				delete(before.Views, index)
+				delete(after.Views, index)
+			}
+		}
+	}
+}
+
+// edit is an entry of the edit history : the settings of the album before and after a change
+type edit struct {
+	Name string
+	// ViewIndex is the page displayed when the edit was made, displayed again when it is undone or redone
+	ViewIndex int
+	Before    albumState
+	After     albumState
+}
+
+// merge extends an edit with the next one, the images and views of both edits having the same indexes
+func (e *edit) merge(next edit) bool {
+	if e.Before.ImageCount != e.After.ImageCount || next.Before.ImageCount != next.After.ImageCount ||
+		e.Before.ViewCount != e.After.ViewCount || next.Before.ViewCount != next.After.ViewCount {
+		return false
+	}
+	for _, state := range []*albumState{&e.Before, &e.After} {
+		if state.Images == nil {
+			state.Images = make(map[int]imageState)
+		}
+		if state.Views == nil {
+			state.Views = make(map[int]viewState)
+		}
+	}
+	for index, state := range next.Before.Images {
+		if _, found := e.Before.Images[index]; !found {
+			e.Before.Images[index] = state
+		}
+	}
+	for index, state := range next.After.Images {
+		e.After.Images[index] = state
+	}
+	for index, state := range next.Before.Views {
+		if _, found := e.Before.Views[index]; !found {
+			e.Before.Views[index] = state
+		}
+	}
+	for index, state := range next.After.Views {
+		e.After.Views[index] = state
+	}
+	e.After.Settings = next.After.Settings
+	return true
+}
+
+// editHistory lists the edits of the album which can be undone, and the undone edits which can be redone
+type editHistory struct {
+	Undo []edit `yaml:",omitempty"`
+	Redo []edit `yaml:",omitempty"`
+
+	// pending is the edit started by begin, for the edits made through an overlay
+	pending *edit
+}
+
+var albumHistory editHistory
+
+// start returns a new edit, made from the current settings of the album
+func (h *editHistory) start(name string) *edit {
+	return &edit{Name: name, ViewIndex: album.CurrentViewIndex, Before: currentState()}
+}
+
+// add records an edit once it is done, if the album changed. The edits repeated by a key held down are merged
+// with the previous edit, to be undone at once.
+func (h *editHistory) add(e *edit, repeated bool, depth int) {
+	e.After = currentState()
+	diffStates(&e.Before, &e.After)
+	if reflect.DeepEqual(e.Before, e.After) {
+		return
+	}
+	if n := len(h.Undo); !(repeated && n > 0 && h.Undo[n-1].Name == e.Name && len(h.Redo) == 0 && h.Undo[n-1].merge(*e)) {
+		h.Undo = append(h.Undo, *e)
+	}
+	if depth > 0 && len(h.Undo) > depth {
+		h.Undo = h.Undo[len(h.Undo)-depth:]
+	}
+	h.Redo = nil
+}
+
+// begin starts an edit ended by commit, used by the overlays changing the album until they are closed
+func (h *editHistory) begin(name string) {
+	h.pending = h.start(name)
+}
+
+// commit records the edit started by begin
+func (h *editHistory) commit(depth int) {
+	if h.pending != nil {
+		h.add(h.pending, false, depth)
+		h.pending = nil
+	}
+}
+
+// record runs a change of the album, which can then be undone, repeated being true when the change is repeated
+// by a key held down
+func (g *GogoReader) record(name string, repeated bool, change func()) {
+	e := albumHistory.start(name)
+	change()
+	albumHistory.add(e, repeated, g.preferences.HistoryDepth)
+}
+
+// restoreAlbum brings the album back to the state recorded by an edit, from the state at the other end of the edit.
+// The images and the views are updated in place, the settings computed by the reader are kept for the views
+// showing the same images.
+func (g *GogoReader) restoreAlbum(state albumState, viewIndex int) {
+	byKey := make(map[string]*ImageData, len(album.Images))
+	for _, img := range album.Images {
+		byKey[img.key()] = img
+	}
+	images := make([]*ImageData, state.ImageCount)
+	for index := range images {
+		imgState, found := state.Images[index]
+		if !found {
+			images[index] = album.Images[index]
+			continue
+		}
+		img := byKey[imgState.key()]
+		if img == nil {
+			img = &ImageData{}
+		}
+		imgState.apply(img)
+		images[index] = img
+	}
+	restored := make(map[string]*ImageData, len(images))
+	for _, img := range images {
+		restored[img.key()] = img
+	}
+
+	views := make([]*ViewData, state.ViewCount)
+	for index := range views {
+		var view *ViewData
+		if index < len(album.Views) {
+			view = album.Views[index]
+		} else {
+			view = &ViewData{}
+		}
+		view.mu.Lock()
+		viewImages := make([]*ImageData, 0, len(view.Images))
+		if viewState, found := state.Views[index]; found {
+			for _, imageIndex := range viewState.Images {
+				viewImages = append(viewImages, images[imageIndex])
+			}
+			view.RotationAngle, view.AngleSet, view.RemoveBorders = viewState.RotationAngle, viewState.AngleSet, viewState.RemoveBorders
+			view.Adjustments, view.AutoLevelsOverride = viewState.Adjustments, viewState.AutoLevelsOverride
+			if !reflect.DeepEqual(viewImages, view.Images) {
+				view.SkewAngle, view.SkewDetected, view.BackgroundColors = 0, false, nil
+			}
+		} else {
+			for _, img := range view.Images {
+				if restoredImg, found := restored[img.key()]; found {
+					viewImages = append(viewImages, restoredImg)
+				} else {
+					log.Printf("Image %s of page %d is missing from the restored album\n", img.key(), index+1)
+				}
+			}
+		}
+		view.Images = viewImages
+		view.bordersOverride = false
+		view.imageSprites = nil
+		// the sprites scaled down in the background before the edit are dropped
+		view.generation++
+		view.mu.Unlock()
+		views[index] = view
+	}
+
+	settings := state.Settings
+	album.Images, album.Views = images, views
+	album.GrayScale, album.AutoLevels, album.PaperWhitening = settings.GrayScale, settings.AutoLevels, settings.PaperWhitening
+	album.AutoDeskew, album.Theme, album.Adjustments = settings.AutoDeskew, settings.Theme, settings.Adjustments
+	album.RemoveBorders, album.ConsistentCrop, album.RightToLeft = settings.RemoveBorders, settings.ConsistentCrop, settings.RightToLeft
+	album.Crop = nil
+	if settings.Crop != nil {
+		options := *settings.Crop
+		album.Crop = &options
+	}
+	if viewIndex >= len(album.Views) {
+		viewIndex = len(album.Views) - 1
+	}
+	album.CurrentViewIndex = viewIndex
+
+	if g.guided.active {
+		g.guided = guidedView{active: true, viewIndex: album.CurrentViewIndex}
+	}
+	g.needsRefresh = true
+}
+
+func (g *GogoReader) undo() {
+	n := len(albumHistory.Undo)
+	if n == 0 {
+		g.notify("Nothing to undo")
+		return
+	}
+	e := albumHistory.Undo[n-1]
+	g.restoreAlbum(e.Before, e.ViewIndex)
+	albumHistory.Undo = albumHistory.Undo[:n-1]
+	albumHistory.Redo = append(albumHistory.Redo, e)
+	g.notify("Undo %s", e.Name)
+}
+
+func (g *GogoReader) redo() {
+	n := len(albumHistory.Redo)
+	if n == 0 {
+		g.notify("Nothing to redo")
+		return
+	}
+	e := albumHistory.Redo[n-1]
+	g.restoreAlbum(e.After, e.ViewIndex)
+	albumHistory.Redo = albumHistory.Redo[:n-1]
+	albumHistory.Undo = append(albumHistory.Undo, e)
+	g.notify("Redo %s", e.Name)
+}
+
+// GetHistoryFile returns the file where the edit history of the album is saved
+func (a *Album) GetHistoryFile(configFolder string) string {
+	return path.Join(configFolder, a.MD5+".history.yml")
+}
+
+// readHistory loads the edit history saved with the album, if any. A history which can not be read is dropped,
+// the settings of the album being loaded anyway.
+func readHistory() error {
+	historyFile := album.GetHistoryFile(configFolder)
+	fileData, err := ioutil.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Printf("Loading edit history from %s\n", historyFile)
+	if err := yaml.Unmarshal(fileData, &albumHistory); err != nil {
+		log.Printf("Unable to read the edit history - %s\n", err.Error())
+		albumHistory = editHistory{}
+	}
+	return nil
+}
+
+// saveHistory saves the edit history next to the settings of the album
+func saveHistory() error {
+	d, err := yaml.Marshal(&albumHistory)
+	if err != nil {
+		return err
+	}
+	historyFile := album.GetHistoryFile(configFolder)
+	log.Printf("Saving edit history to %s\n", historyFile)
+	return ioutil.WriteFile(historyFile, d, 0644)
+}
+
+// removeHistory deletes the edit history saved with the album, if any
+func removeHistory() error {
+	historyFile := album.GetHistoryFile(configFolder)
+	err := os.Remove(historyFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.Printf("Removed edit history %s\n", historyFile)
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// useAlbum replaces the album being read for the duration of a test
+func useAlbum(t *testing.T, a *Album) {
+	saved := album
+	album = *a
+	t.Cleanup(func() { album = saved })
+}
+
+func TestDiffStates(t *testing.T) {
+	useAlbum(t, newAlbum("a", "b", "c"))
+	before := currentState()
+	album.Images[1].Rotation = Right
+	album.Views[2].Adjustments.Brightness = 20
+	after := currentState()
+
+	diffStates(&before, &after)
+	if len(before.Images) != 1 || before.Images[1].Rotation != None || after.Images[1].Rotation != Right {
+		t.Errorf("images %v -> %v, want only the rotation of b", before.Images, after.Images)
+	}
+	if len(before.Views) != 1 || after.Views[2].Adjustments.Brightness != 20 {
+		t.Errorf("views %v -> %v, want only the adjustments of the last view", before.Views, after.Views)
+	}
+
+	// the views are all kept when a view is removed, as their indexes change
+	before = currentState()
+	album.hideImage(album.Images[0])
+	after = currentState()
+	diffStates(&before, &after)
+	if len(before.Views) != 3 || len(after.Views) != 2 {
+		t.Errorf("%d views -> %d views, want 3 -> 2", len(before.Views), len(after.Views))
+	}
+	if len(before.Images) != 1 || !before.Images[0].Visible || after.Images[0].Visible {
+		t.Errorf("images %v -> %v, want only a being hidden", before.Images, after.Images)
+	}
+}
+
+func TestEditMerge(t *testing.T) {
+	brightness := func(value float64) viewState {
+		return viewState{Images: []int{0}, Adjustments: Adjustments{Brightness: value}}
+	}
+	first := edit{
+		Name:   "brightness",
+		Before: albumState{ImageCount: 2, ViewCount: 2, Views: map[int]viewState{0: brightness(0)}},
+		After:  albumState{ImageCount: 2, ViewCount: 2, Views: map[int]viewState{0: brightness(5)}},
+	}
+	second := edit{
+		Name: "brightness",
+		Before: albumState{ImageCount: 2, ViewCount: 2, Views: map[int]viewState{0: brightness(5)},
+			Images: map[int]imageState{1: {FileName: "b", Visible: true}}},
+		After: albumState{ImageCount: 2, ViewCount: 2, Views: map[int]viewState{0: brightness(10)},
+			Images: map[int]imageState{1: {FileName: "b", Visible: true, Rotation: Left}}, Settings: albumSettings{GrayScale: true}},
+	}
+
+	if !first.merge(second) {
+		t.Fatal("edits of the same pages not merged")
+	}
+	if got := first.Before.Views[0].Adjustments.Brightness; got != 0 {
+		t.Errorf("brightness before the edits %v, want 0", got)
+	}
+	if got := first.After.Views[0].Adjustments.Brightness; got != 10 {
+		t.Errorf("brightness after the edits %v, want 10", got)
+	}
+	if first.Before.Images[1].Rotation != None || first.After.Images[1].Rotation != Left {
+		t.Errorf("images %v -> %v, want the image changed by the second edit", first.Before.Images, first.After.Images)
+	}
+	if !first.After.Settings.GrayScale {
+		t.Error("settings of the second edit not kept")
+	}
+
+	// an edit adding a page can not be merged, the indexes of the pages being shifted
+	third := edit{
+		Name:   "brightness",
+		Before: albumState{ImageCount: 2, ViewCount: 2},
+		After:  albumState{ImageCount: 2, ViewCount: 3},
+	}
+	merged := first
+	if merged.merge(third) {
+		t.Error("edit changing the number of pages merged")
+	}
+}
+
+func TestHistoryMergesRepeatedEdits(t *testing.T) {
+	useAlbum(t, newAlbum("a", "b"))
+	var history editHistory
+	brighter := func(repeated bool) {
+		e := history.start("brightness")
+		album.Views[0].Adjustments.Brightness += 5
+		history.add(e, repeated, 0)
+	}
+
+	brighter(false)
+	brighter(true)
+	brighter(true)
+	if len(history.Undo) != 1 {
+		t.Fatalf("%d edits, want the repeated edits merged in one", len(history.Undo))
+	}
+	if before, after := history.Undo[0].Before.Views[0], history.Undo[0].After.Views[0]; before.Adjustments.Brightness != 0 || after.Adjustments.Brightness != 15 {
+		t.Errorf("brightness %v -> %v, want 0 -> 15", before.Adjustments.Brightness, after.Adjustments.Brightness)
+	}
+
+	// a new press of the key is a new edit
+	brighter(false)
+	if len(history.Undo) != 2 {
+		t.Errorf("%d edits, want 2", len(history.Undo))
+	}
+
+	// an edit leaving the album unchanged is not recorded
+	history.add(history.start("nothing"), false, 0)
+	if len(history.Undo) != 2 {
+		t.Errorf("%d edits after an empty edit, want 2", len(history.Undo))
+	}
+}
+
+func TestRestoreAlbum(t *testing.T) {
+	useAlbum(t, newAlbum("a", "b", "c"))
+	g := &GogoReader{}
+	b, c := album.Images[1], album.Images[2]
+	// the view of c shows b once the edit is undone
+	view := album.Views[2]
+	generation := view.generation
+
+	e := edit{Before: currentState()}
+	c.Rotation = Left
+	album.hideImage(b)
+	album.RightToLeft = true
+	e.After = currentState()
+	diffStates(&e.Before, &e.After)
+
+	g.restoreAlbum(e.Before, 0)
+	if got, want := pages(&album), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pages after undo %q, want %q", got, want)
+	}
+	if album.Images[1] != b || album.Images[2] != c || album.Views[2].Images[0] != c {
+		t.Error("the images are not restored in place")
+	}
+	if !b.Visible || c.Rotation != None || album.RightToLeft {
+		t.Errorf("b visible %v, c rotation %v, right to left %v after undo", b.Visible, c.Rotation, album.RightToLeft)
+	}
+	if album.Views[1] != view || view.generation == generation {
+		t.Error("the sprites prepared before the undo are not dropped")
+	}
+
+	g.restoreAlbum(e.After, 5)
+	if got, want := pages(&album), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pages after redo %q, want %q", got, want)
+	}
+	if b.Visible || c.Rotation != Left || !album.RightToLeft {
+		t.Errorf("b visible %v, c rotation %v, right to left %v after redo", b.Visible, c.Rotation, album.RightToLeft)
+	}
+	if album.CurrentViewIndex != 1 {
+		t.Errorf("current view %d, want the last view", album.CurrentViewIndex)
+	}
+
+	// an image of an unchanged view which is not in the album any more is dropped
+	state := currentState()
+	album.Views[0].Images = append(album.Views[0].Images, &ImageData{FileName: "deleted"})
+	delete(state.Views, 0)
+	g.restoreAlbum(state, 0)
+	if got, want := pages(&album), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pages %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+const (
+	wheelUp   = "WheelUp"
+	wheelDown = "WheelDown"
+
+	// gamepadPrefix starts the names of the gamepad buttons, like GamepadA or GamepadStart
+	gamepadPrefix = "Gamepad"
+)
+
+// Binding is a key, mouse button, mouse wheel direction or gamepad button, with the modifiers that must be held down
+type Binding struct {
+	Button pixelgl.Button
+	// Wheel is 1 for the mouse wheel going up, -1 for down and 0 when the binding is a button
+	Wheel int
+	// Gamepad is true when the binding is the GamepadButton of any connected gamepad
+	Gamepad       bool
+	GamepadButton pixelgl.GamepadButton
+
+	Ctrl  bool
+	Alt   bool
+	Shift bool
+}
+
+// buttonsByName maps the lower case names of the pixelgl buttons to the buttons
+var buttonsByName = func() map[string]pixelgl.Button {
+	buttons := make(map[string]pixelgl.Button)
+	for button := pixelgl.MouseButton1; button <= pixelgl.KeyLast; button++ {
+		if name := button.String(); name != "Invalid" {
+			buttons[strings.ToLower(name)] = button
+		}
+	}
+	// shorter names for the mouse buttons
+	buttons["mouseleft"] = pixelgl.MouseButtonLeft
+	buttons["mouseright"] = pixelgl.MouseButtonRight
+	buttons["mousemiddle"] = pixelgl.MouseButtonMiddle
+	return buttons
+}()
+
+// gamepadButtonNames are the names of the gamepad buttons, without the Gamepad prefix
+var gamepadButtonNames = map[pixelgl.GamepadButton]string{
+	pixelgl.ButtonA:           "A",
+	pixelgl.ButtonB:           "B",
+	pixelgl.ButtonX:           "X",
+	pixelgl.ButtonY:           "Y",
+	pixelgl.ButtonLeftBumper:  "LeftBumper",
+	pixelgl.ButtonRightBumper: "RightBumper",
+	pixelgl.ButtonBack:        "Back",
+	pixelgl.ButtonStart:       "Start",
+	pixelgl.ButtonGuide:       "Guide",
+	pixelgl.ButtonLeftThumb:   "LeftThumb",
+	pixelgl.ButtonRightThumb:  "RightThumb",
+	pixelgl.ButtonDpadUp:      "DpadUp",
+	pixelgl.ButtonDpadRight:   "DpadRight",
+	pixelgl.ButtonDpadDown:    "DpadDown",
+	pixelgl.ButtonDpadLeft:    "DpadLeft",
+}
+
+// gamepadButtonsByName maps the lower case names of the gamepad buttons to the buttons
+var gamepadButtonsByName = func() map[string]pixelgl.GamepadButton {
+	buttons := make(map[string]pixelgl.GamepadButton)
+	for button, name := range gamepadButtonNames {
+		buttons[strings.ToLower(name)] = button
+	}
+	// PlayStation names
+	buttons["cross"] = pixelgl.ButtonCross
+	buttons["circle"] = pixelgl.ButtonCircle
+	buttons["square"] = pixelgl.ButtonSquare
+	buttons["triangle"] = pixelgl.ButtonTriangle
+	return buttons
+}()
+
+// ParseBinding reads a binding such as "B", "Shift+B", "Ctrl+G", "MouseButtonLeft" or "WheelDown"
+func ParseBinding(value string) (Binding, error) {
+	var binding Binding
+	parts := strings.Split(value, "+")
+	for _, modifier := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(modifier)) {
+		case "ctrl", "control":
+			binding.Ctrl = true
+		case "alt":
+			binding.Alt = true
+		case "shift":
+			binding.Shift = true
+		default:
+			return binding, fmt.Errorf("unknown modifier %s in %s", modifier, value)
+		}
+	}
+
+	name := strings.ToLower(strings.TrimSpace(parts[len(parts)-1]))
+	switch name {
+	case strings.ToLower(wheelUp):
+		binding.Wheel = 1
+	case strings.ToLower(wheelDown):
+		binding.Wheel = -1
+	default:
+		if strings.HasPrefix(name, strings.ToLower(gamepadPrefix)) {
+			button, found := gamepadButtonsByName[strings.TrimPrefix(name, strings.ToLower(gamepadPrefix))]
+			if !found {
+				return binding, fmt.Errorf("unknown gamepad button %s in %s", parts[len(parts)-1], value)
+			}
+			if binding.Ctrl || binding.Alt || binding.Shift {
+				return binding, fmt.Errorf("modifiers are not supported with gamepad buttons in %s", value)
+			}
+			binding.Gamepad = true
+			binding.GamepadButton = button
+			return binding, nil
+		}
+		button, found := buttonsByName[name]
+		if !found {
+			return binding, fmt.Errorf("unknown key %s in %s", parts[len(parts)-1], value)
+		}
+		binding.Button = button
+	}
+	return binding, nil
+}
+
+func (b Binding) String() string {
+	var parts []string
+	if b.Ctrl {
+		parts = append(parts, "Ctrl")
+	}
+	if b.Alt {
+		parts = append(parts, "Alt")
+	}
+	if b.Shift {
+		parts = append(parts, "Shift")
+	}
+	switch {
+	case b.Gamepad:
+		parts = append(parts, gamepadPrefix+gamepadButtonNames[b.GamepadButton])
+	case b.Wheel > 0:
+		parts = append(parts, wheelUp)
+	case b.Wheel < 0:
+		parts = append(parts, wheelDown)
+	default:
+		parts = append(parts, b.Button.String())
+	}
+	return strings.Join(parts, "+")
+}
+
+// IsMouse returns true for bindings using a mouse button or the mouse wheel
+func (b Binding) IsMouse() bool {
+	if b.Gamepad {
+		return false
+	}
+	return b.Wheel != 0 || (b.Button >= pixelgl.MouseButton1 && b.Button <= pixelgl.MouseButtonLast)
+}
+
+// Triggered returns true when the binding was activated during the last update of the window,
+// repeat allows a key which is held down to trigger the binding again
+func (b Binding) Triggered(win *pixelgl.Window, repeat bool) bool {
+	if b.Gamepad {
+		for _, joystick := range connectedGamepads(win) {
+			if win.JoystickJustPressed(joystick, b.GamepadButton) {
+				return true
+			}
+		}
+		return false
+	}
+	if !b.modifiersHeld(win.Pressed) {
+		return false
+	}
+	if b.Wheel > 0 {
+		return win.MouseScroll().Y > 0
+	}
+	if b.Wheel < 0 {
+		return win.MouseScroll().Y < 0
+	}
+	return win.JustPressed(b.Button) || (repeat && win.Repeated(b.Button))
+}
+
+// modifiersHeld returns true when exactly the modifiers of the binding are held down, pressed telling if a key is down
+func (b Binding) modifiersHeld(pressed func(pixelgl.Button) bool) bool {
+	if b.Ctrl != (pressed(pixelgl.KeyLeftControl) || pressed(pixelgl.KeyRightControl)) {
+		return false
+	}
+	if b.Alt != (pressed(pixelgl.KeyLeftAlt) || pressed(pixelgl.KeyRightAlt)) {
+		return false
+	}
+	return b.Shift == (pressed(pixelgl.KeyLeftShift) || pressed(pixelgl.KeyRightShift))
+}
+
+// Repeated returns true when the binding was triggered by a key held down rather than a new press
+func (b Binding) Repeated(win *pixelgl.Window) bool {
+	return !b.Gamepad && b.Wheel == 0 && !win.JustPressed(b.Button)
+}
+
+// KeyMap binds the actions to keys and mouse buttons
+type KeyMap struct {
+	bindings map[string][]Binding
+	// Warnings lists the invalid and conflicting bindings that were ignored
+	Warnings []string
+}
+
+// NewKeyMap builds the key map of the given actions, the bindings configured by the user
+// (by action name) replace the default bindings of the actions
+func NewKeyMap(actions []*Action, configured map[string][]string) *KeyMap {
+	keyMap := &KeyMap{bindings: make(map[string][]Binding)}
+
+	known := make(map[string]bool)
+	for _, action := range actions {
+		known[action.Name] = true
+	}
+	names := make([]string, 0, len(configured))
+	for name := range configured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			keyMap.warn("unknown action %s in key bindings", name)
+		}
+	}
+
+	// bound maps each binding to the action using it, configured bindings are registered first so they win over defaults
+	bound := make(map[string]string)
+	register := func(action *Action, values []string) {
+		for _, value := range values {
+			binding, err := ParseBinding(value)
+			if err != nil {
+				keyMap.warn("%s : %s", action.Name, err.Error())
+				continue
+			}
+			if owner, found := bound[binding.String()]; found {
+				if owner != action.Name {
+					keyMap.warn("%s is bound to both %s and %s, keeping %s", binding, owner, action.Name, owner)
+				}
+				continue
+			}
+			bound[binding.String()] = action.Name
+			keyMap.bindings[action.Name] = append(keyMap.bindings[action.Name], binding)
+		}
+	}
+	for _, action := range actions {
+		if values, found := configured[action.Name]; found {
+			register(action, values)
+		}
+	}
+	for _, action := range actions {
+		if _, found := configured[action.Name]; !found {
+			register(action, action.Defaults)
+		}
+	}
+
+	return keyMap
+}
+
+func (k *KeyMap) warn(format string, args ...interface{}) {
+	k.Warnings = append(k.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Bindings returns the bindings of the given action
+func (k *KeyMap) Bindings(actionName string) []Binding {
+	return k.bindings[actionName]
+}
+
+// Describe returns the bindings of the given action, in a human readable form
+func (k *KeyMap) Describe(actionName string) string {
+	var names []string
+	for _, binding := range k.bindings[actionName] {
+		names = append(names, binding.String())
+	}
+	if len(names) == 0 {
+		return "(unbound)"
+	}
+	return strings.Join(names, " / ")
+}
+
+// Help returns the list of the actions and their bindings, grouped by category
+func (k *KeyMap) Help(actions []*Action) []string {
+	var lines []string
+	category := ""
+	for _, action := range actions {
+		if action.Category != category {
+			if category != "" {
+				lines = append(lines, "")
+			}
+			category = action.Category
+			lines = append(lines, category)
+		}
+		lines = append(lines, fmt.Sprintf("  %-28s %s", k.Describe(action.Name), action.Description))
+	}
+	return lines
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+func TestParseBinding(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Binding
+		name  string
+	}{
+		{"B", Binding{Button: pixelgl.KeyB}, "B"},
+		{"shift+b", Binding{Button: pixelgl.KeyB, Shift: true}, "Shift+B"},
+		{"Control + Alt + PageDown", Binding{Button: pixelgl.KeyPageDown, Ctrl: true, Alt: true}, "Ctrl+Alt+PageDown"},
+		{"MouseLeft", Binding{Button: pixelgl.MouseButtonLeft}, "MouseButtonLeft"},
+		{"WheelUp", Binding{Wheel: 1}, "WheelUp"},
+		{"Ctrl+WheelDown", Binding{Wheel: -1, Ctrl: true}, "Ctrl+WheelDown"},
+		{"GamepadDpadLeft", Binding{Gamepad: true, GamepadButton: pixelgl.ButtonDpadLeft}, "GamepadDpadLeft"},
+		{"GamepadCross", Binding{Gamepad: true, GamepadButton: pixelgl.ButtonCross}, "GamepadA"},
+	}
+	for _, test := range tests {
+		binding, err := ParseBinding(test.value)
+		if err != nil {
+			t.Errorf("ParseBinding(%q) : %v", test.value, err)
+			continue
+		}
+		if binding != test.want {
+			t.Errorf("ParseBinding(%q) = %+v, want %+v", test.value, binding, test.want)
+		}
+		if binding.String() != test.name {
+			t.Errorf("ParseBinding(%q).String() = %q, want %q", test.value, binding.String(), test.name)
+		}
+	}
+
+	for _, value := range []string{"", "Hyper+B", "NoSuchKey", "GamepadZ", "Shift+GamepadA"} {
+		if binding, err := ParseBinding(value); err == nil {
+			t.Errorf("ParseBinding(%q) = %v, want an error", value, binding)
+		}
+	}
+}
+
+func TestModifiersHeld(t *testing.T) {
+	held := func(keys ...pixelgl.Button) func(pixelgl.Button) bool {
+		return func(button pixelgl.Button) bool {
+			for _, key := range keys {
+				if key == button {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	shiftB := Binding{Button: pixelgl.KeyB, Shift: true}
+
+	if !shiftB.modifiersHeld(held(pixelgl.KeyLeftShift)) {
+		t.Error("Shift+B not triggered with the left shift held down")
+	}
+	if !shiftB.modifiersHeld(held(pixelgl.KeyRightShift, pixelgl.KeyB)) {
+		t.Error("Shift+B not triggered with the right shift held down")
+	}
+	if shiftB.modifiersHeld(held()) {
+		t.Error("Shift+B triggered without shift")
+	}
+	if shiftB.modifiersHeld(held(pixelgl.KeyLeftShift, pixelgl.KeyRightControl)) {
+		t.Error("Shift+B triggered by Ctrl+Shift+B")
+	}
+	if (Binding{Button: pixelgl.KeyB}).modifiersHeld(held(pixelgl.KeyLeftAlt)) {
+		t.Error("B triggered by Alt+B")
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	testActions := []*Action{
+		{Name: "first", Defaults: []string{"A", "B"}},
+		{Name: "second", Defaults: []string{"B", "C"}},
+		{Name: "third", Defaults: []string{"D"}},
+	}
+
+	keyMap := NewKeyMap(testActions, nil)
+	if got := keyMap.Describe("second"); got != "C" {
+		t.Errorf("second is bound to %s, want C as B belongs to first", got)
+	}
+	if len(keyMap.Warnings) != 1 {
+		t.Errorf("warnings %q, want one conflict", keyMap.Warnings)
+	}
+
+	// configured bindings replace the defaults and win over the defaults of the other actions
+	keyMap = NewKeyMap(testActions, map[string][]string{
+		"third":   {"A", "Ctrl+D", "NoSuchKey"},
+		"unknown": {"E"},
+	})
+	want := map[string]string{
+		"first":  "B",
+		"second": "C",
+		"third":  "A / Ctrl+D",
+	}
+	for name, bindings := range want {
+		if got := keyMap.Describe(name); got != bindings {
+			t.Errorf("%s is bound to %s, want %s", name, got, bindings)
+		}
+	}
+	wantWarnings := []string{
+		"unknown action unknown in key bindings",
+		"third : unknown key NoSuchKey in NoSuchKey",
+		"A is bound to both third and first, keeping third",
+		"B is bound to both first and second, keeping first",
+	}
+	if !reflect.DeepEqual(keyMap.Warnings, wantWarnings) {
+		t.Errorf("warnings %q, want %q", keyMap.Warnings, wantWarnings)
+	}
+}
+
+func TestDefaultBindingsDoNotConflict(t *testing.T) {
+	keyMap := NewKeyMap(actions, nil)
+	for _, warning := range keyMap.Warnings {
+		t.Error(warning)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// maximum distance in pixels between the press and the release of the button for a click
+const clickTolerance = 10.0
+
+// clickZoneAction returns the name of the action bound to the zone of the window at the given horizontal position
+func (g *GogoReader) clickZoneAction(x float64) string {
+	zones := g.preferences.ClickZones
+	if !zones.Enabled {
+		return zones.Center
+	}
+	left, right := zones.Left, zones.Right
+	if album.RightToLeft {
+		left, right = right, left
+	}
+	sideWidth := g.size.X * zones.SideWidth
+	if x < sideWidth {
+		return left
+	}
+	if x > g.size.X-sideWidth {
+		return right
+	}
+	return zones.Center
+}
+
+// checkClickZones returns the click zone actions that do not exist
+func checkClickZones(zones ClickZones) []string {
+	var warnings []string
+	for _, name := range []string{zones.Left, zones.Center, zones.Right} {
+		if name != "" && findAction(name) == nil {
+			warnings = append(warnings, fmt.Sprintf("unknown action %s in click zones", name))
+		}
+	}
+	return warnings
+}
+
+// updateMouse handles the clicks on the zones of the window and the swipe gestures,
+// mouseUsed is true when the mouse was already used by another part of the interface
+func (g *GogoReader) updateMouse(mouseUsed bool) {
+	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
+		g.dragging = !mouseUsed
+		g.dragStart = g.win.MousePosition()
+		// Alt + drag draws a new panel in the guided view
+		g.guided.drawing = g.dragging && g.guided.active && (g.win.Pressed(pixelgl.KeyLeftAlt) || g.win.Pressed(pixelgl.KeyRightAlt))
+		return
+	}
+	if !g.dragging || !g.win.JustReleased(pixelgl.MouseButtonLeft) {
+		return
+	}
+	g.dragging = false
+	if g.guided.drawing {
+		g.guided.drawing = false
+		g.record("add-panel", false, func() { g.addPanel(g.dragStart, g.win.MousePosition()) })
+		return
+	}
+
+	move := g.win.MousePosition().Sub(g.dragStart)
+	swipeDistance := g.size.X * g.preferences.SwipeDistance
+	if g.preferences.SwipeDistance > 0 && math.Abs(move.X) >= swipeDistance && math.Abs(move.X) > 2*math.Abs(move.Y) {
+		// dragging the page to the left shows the next page, unless reading from right to left
+		if (move.X < 0) != album.RightToLeft {
+			g.runAction("next-page")
+		} else {
+			g.runAction("previous-page")
+		}
+		return
+	}
+	if move.Len() <= clickTolerance {
+		g.runAction(g.clickZoneAction(g.dragStart.X))
+	}
+}
+
+// runAction runs the action with the given name, if it exists
+func (g *GogoReader) runAction(name string) {
+	if action := findAction(name); action != nil {
+		g.run(action, false)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"unicode"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/mozvip/gomics/ui"
+)
+
+// openDialog displays a text input, confirm is called with the typed value when Enter is pressed
+func (g *GogoReader) openDialog(prompt string, accept func(r rune) bool, confirm func(value string)) {
+	g.dialog = ui.NewInputDialog(prompt, accept)
+	g.dialogConfirm = confirm
+}
+
+func (g *GogoReader) updateDialog() {
+	g.dialog.Type(g.win.Typed())
+	if g.win.JustPressed(pixelgl.KeyBackspace) || g.win.Repeated(pixelgl.KeyBackspace) {
+		g.dialog.DeleteLast()
+	}
+	if g.win.JustPressed(pixelgl.KeyEnter) || g.win.JustPressed(pixelgl.KeyKPEnter) {
+		value := g.dialog.Value
+		g.dialog = nil
+		g.dialogConfirm(value)
+	} else if g.win.JustPressed(pixelgl.KeyEscape) {
+		g.dialog = nil
+	}
+}
+
+func (g *GogoReader) openGoToPageDialog() {
+	prompt := fmt.Sprintf("Go to page (1-%d) :", len(album.Views))
+	g.openDialog(prompt, unicode.IsDigit, func(value string) {
+		page, err := strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+		if page < 1 {
+			page = 1
+		} else if page > len(album.Views) {
+			page = len(album.Views)
+		}
+		g.goTo(page - 1)
+	})
+}
+
+// updateScrubber shows the scrubber when the mouse is at the bottom of the window,
+// it returns true when the mouse click was used by the scrubber
+func (g *GogoReader) updateScrubber() bool {
+	g.scrubber.Count = len(album.Views)
+	g.scrubber.Position = album.CurrentViewIndex
+	g.scrubber.Markers = album.ChapterStarts()
+
+	mousePosition := g.win.MousePosition()
+	g.scrubberVisible = g.dialog != nil || (g.win.MouseInsideWindow() && mousePosition.Y <= g.scrubber.Height*2)
+	if !g.scrubberVisible || mousePosition.Y > g.scrubber.Height {
+		return false
+	}
+
+	if g.win.JustPressed(pixelgl.MouseButtonLeft) {
+		g.goTo(g.scrubber.PositionAt(g.win.Bounds(), mousePosition))
+		return true
+	}
+	return false
+}
+
+func (g *GogoReader) drawScrubber() {
+	if !g.scrubberVisible {
+		return
+	}
+
+	imd := imdraw.New(nil)
+	g.scrubber.Draw(imd, g.win.Bounds())
+	imd.Draw(g.win)
+
+	mousePosition := g.win.MousePosition()
+	if !g.win.MouseInsideWindow() || mousePosition.Y > g.scrubber.Height {
+		return
+	}
+
+	// preview of the page under the mouse
+	position := g.scrubber.PositionAt(g.win.Bounds(), mousePosition)
+	label := text.New(pixel.ZV, fontAtlas)
+	fmt.Fprintf(label, "%d / %d", position+1, len(album.Views))
+
+	previewSize := pixel.V(label.Bounds().W(), 0)
+	sprite := g.thumbnails.Sprite(album.Views[position].Images[0])
+	if sprite != nil {
+		previewSize.X = math.Max(previewSize.X, sprite.Frame().W())
+		previewSize.Y = sprite.Frame().H()
+	}
+	previewSize = previewSize.Add(pixel.V(8, 8+label.Bounds().H()))
+
+	x := g.scrubber.PositionX(g.win.Bounds(), position)
+	x = math.Max(previewSize.X/2, math.Min(x, g.size.X-previewSize.X/2))
+	box := pixel.R(x-previewSize.X/2, g.scrubber.Height+4, x+previewSize.X/2, g.scrubber.Height+4+previewSize.Y)
+
+	imd = imdraw.New(nil)
+	imd.Color = color.RGBA{30, 30, 30, 220}
+	imd.Push(box.Min, box.Max)
+	imd.Rectangle(0)
+	imd.Draw(g.win)
+
+	label.Draw(g.win, pixel.IM.Moved(pixel.V(box.Center().X-label.Bounds().W()/2, box.Min.Y+4-label.Bounds().Min.Y)))
+	if sprite != nil {
+		sprite.Draw(g.win, pixel.IM.Moved(pixel.V(box.Center().X, box.Max.Y-4-sprite.Frame().H()/2)))
+	}
+}
+
+func (g *GogoReader) drawDialog() {
+	if g.dialog != nil {
+		g.dialog.Draw(g.win, fontAtlas, g.win.Bounds())
+	}
+}
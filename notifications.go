@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/mozvip/gomics/ui"
+)
+
+const messageTimeout = 2.5
+
+// notify displays a short message on top of the current page
+func (g *GogoReader) notify(format string, args ...interface{}) {
+	g.messagesMu.Lock()
+	defer g.messagesMu.Unlock()
+	g.messages = append(g.messages, ui.NewMessage(fmt.Sprintf(format, args...), messageTimeout))
+}
+
+// notifyError logs the error and displays it on top of the current page
+func (g *GogoReader) notifyError(err error) {
+	log.Printf("Error : %s\n", err.Error())
+	g.messagesMu.Lock()
+	defer g.messagesMu.Unlock()
+	g.messages = append(g.messages, ui.NewErrorMessage(err, 2*messageTimeout))
+}
+
+func (g *GogoReader) drawMessages() {
+	g.messagesMu.Lock()
+	defer g.messagesMu.Unlock()
+
+	now := time.Now()
+	messages := g.messages[:0]
+	for _, message := range g.messages {
+		if !message.Expired(now) {
+			messages = append(messages, message)
+		}
+	}
+	g.messages = messages
+
+	// most recent messages are displayed at the bottom, above the scrubber
+	y := g.scrubber.Height + 10
+	for i := len(g.messages) - 1; i >= 0; i-- {
+		y += g.messages[i].Draw(g.win, fontAtlas, pixel.V(10, y), now) + 5
+	}
+}
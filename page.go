@@ -1,8 +1,10 @@
 package main
 
 import (
+	"image"
 	"sync"
 
+	"github.com/disintegration/imaging"
 	"github.com/faiface/pixel"
 )
 
@@ -16,18 +18,128 @@ type ImageData struct {
 	Bottom int
 	Left   int
 	Right  int
+	// ManualCrop is set once the crop is drawn in the crop editor, the borders are then no longer removed automatically
+	ManualCrop bool `yaml:",omitempty"`
+
+	// Panels are the panels of the image in reading order, used by the guided view
+	Panels []Panel `yaml:",omitempty"`
+	// PanelsEdited is set once the detected panels are corrected by the user
+	PanelsEdited bool `yaml:",omitempty"`
+
+	// Half is the page shown by a virtual image created by splitting a spread, the archive entry being unchanged
+	Half Half `yaml:",omitempty"`
+	// Gutter is the position of the fold of the spread, relative to its width, and Overlap the part of
+	// the width each half extends past the fold
+	Gutter  float64 `yaml:",omitempty"`
+	Overlap float64 `yaml:",omitempty"`
+	// SpreadRotation is the rotation of the whole spread, applied before it is split, Rotation being
+	// the rotation of the half
+	SpreadRotation Rotation `yaml:",omitempty"`
+
+	// Flag is set by the page analysis on the images which are probably not part of the comic
+	Flag PageFlag `yaml:",omitempty"`
+}
+
+// readImage reads the entry of an image from the archive, rotated and reduced to its half of a spread
+func readImage(imgData *ImageData) (image.Image, error) {
+	return decodeImage(imgData, comicBook.ReadEntry)
+}
+
+// peekImage reads an image like readImage without keeping the entry in the image cache, for the analyses going
+// through many pages of the album
+func peekImage(imgData *ImageData) (image.Image, error) {
+	return decodeImage(imgData, comicBook.ReadEntryUncached)
+}
+
+func decodeImage(imgData *ImageData, readEntry func(fileName string) (image.Image, error)) (image.Image, error) {
+	img, err := decodePage(imgData, readEntry)
+	if err != nil {
+		return nil, err
+	}
+	return rotate(img, imgData.Rotation), nil
+}
+
+// decodePage reads the part of the entry shown by an image, before the image is rotated : the whole entry,
+// or a half of a spread once the spread is rotated
+func decodePage(imgData *ImageData, readEntry func(fileName string) (image.Image, error)) (image.Image, error) {
+	img, err := readEntry(imgData.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if imgData.Half != HalfNone {
+		img = rotate(img, imgData.SpreadRotation)
+		img = imaging.Crop(img, imgData.halfRect(img.Bounds()))
+	}
+	return img, nil
+}
+
+func rotate(img image.Image, rotation Rotation) image.Image {
+	if rotation == Left {
+		return imaging.Rotate90(img)
+	} else if rotation == Right {
+		return imaging.Rotate270(img)
+	}
+	return img
+}
+
+// Panel is a rectangle of an image, in pixels from the top left corner of the rotated image
+type Panel struct {
+	Left   int
+	Top    int
+	Right  int
+	Bottom int
+}
+
+// pictureToImage converts a rectangle between picture coordinates (from the bottom left corner)
+// and image coordinates (from the top left corner), the conversion is its own inverse
+func pictureToImage(bounds image.Rectangle, rect image.Rectangle) image.Rectangle {
+	return image.Rect(rect.Min.X, bounds.Min.Y+bounds.Max.Y-rect.Max.Y, rect.Max.X, bounds.Min.Y+bounds.Max.Y-rect.Min.Y)
+}
+
+func panelFromRect(r image.Rectangle) Panel {
+	return Panel{Left: r.Min.X, Top: r.Min.Y, Right: r.Max.X, Bottom: r.Max.Y}
+}
+
+func (p Panel) Rect() image.Rectangle {
+	return image.Rect(p.Left, p.Top, p.Right, p.Bottom)
 }
 
 type ViewData struct {
 	mu sync.Mutex
 
-	Images           []*ImageData
-	RotationAngle    float64
+	Images []*ImageData
+	// RotationAngle is the rotation of the view in degrees, counter-clockwise
+	RotationAngle float64
+	// AngleSet is true once the rotation was set by the user, the angle proposed by the skew detection is then ignored
+	AngleSet bool `yaml:",omitempty"`
+	// SkewAngle is the rotation proposed by the skew detection, SkewDetected is false until the detection runs
+	SkewAngle        float64 `yaml:",omitempty"`
+	SkewDetected     bool    `yaml:",omitempty"`
 	BackgroundColors []pixel.RGBA
 	RemoveBorders    bool
 	bordersOverride  bool
+	// Adjustments are added to the adjustments of the album
+	Adjustments Adjustments `yaml:",omitempty"`
+	// AutoLevelsOverride applies the opposite of the auto levels setting of the album to this view
+	AutoLevelsOverride bool `yaml:",omitempty"`
 
 	imageSprites []*pixel.Sprite
+	// frames of the sprites and bounds of their pictures, in pixels of the images before they are scaled to the window
+	imageFrames []pixel.Rect
+	imageBounds []pixel.Rect
+	// cropRects are the parts of the images displayed out of the crop editor, in image coordinates
+	cropRects []image.Rectangle
+	// spriteScales are the sizes of the sprites relative to the sizes of the images
+	spriteScales []float64
+	// preparedSize is the size of the window the sprites were scaled for
+	preparedSize pixel.Vec
+	// outdated is set when the view must be prepared again, its sprites being drawn in the meantime
+	outdated bool
+	// generation counts the preparations of the view, the sprites scaled down in the background are only kept
+	// for the last one
+	generation int
+	// editingCrop displays the whole images, while their crop is edited with the mouse
+	editingCrop bool
 
 	totalWidth float64
 	maxHeight  float64
@@ -36,8 +148,8 @@ type ViewData struct {
 func (v *ViewData) updateSize() {
 
 	var totalWidth, maxHeight float64
-	for _, sprite := range v.imageSprites {
-		spriteW, spriteH := sprite.Frame().W(), sprite.Frame().H()
+	for _, frame := range v.imageFrames {
+		spriteW, spriteH := frame.W(), frame.H()
 		totalWidth += spriteW
 		if spriteH > maxHeight {
 			maxHeight = spriteH
@@ -47,6 +159,11 @@ func (v *ViewData) updateSize() {
 	v.maxHeight = maxHeight
 }
 
+// spriteMatrix returns the matrix drawing the sprite of an image at the size of the image
+func (v *ViewData) spriteMatrix(index int) pixel.Matrix {
+	return pixel.IM.Scaled(pixel.ZV, 1/v.spriteScales[index])
+}
+
 func (p *ViewData) RotateRight() {
 	for i := 0; i < len(p.Images); i++ {
 		if p.Images[i].Rotation == None {
@@ -74,13 +191,33 @@ func (p *ViewData) ToggleBorder(globalSetting bool) {
 	p.bordersOverride = p.RemoveBorders != globalSetting
 }
 
+// ClearPanels removes the panels of the images, they are detected again when the view is prepared
+func (p *ViewData) ClearPanels() {
+	for _, img := range p.Images {
+		img.Panels = nil
+		img.PanelsEdited = false
+	}
+}
+
+// flag returns the flag of the first flagged image of the view
+func (p *ViewData) flag() PageFlag {
+	for _, img := range p.Images {
+		if img.Flag != FlagNone {
+			return img.Flag
+		}
+	}
+	return FlagNone
+}
+
 func (p *ViewData) Reset() {
 	p.bordersOverride = false
+	p.ClearPanels()
 	for i := 0; i < len(p.Images); i++ {
 		p.Images[i].Top = 0
 		p.Images[i].Bottom = 0
 		p.Images[i].Left = 0
 		p.Images[i].Right = 0
+		p.Images[i].ManualCrop = false
 	}
 	p.updateSize()
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"image"
+
+	"github.com/mozvip/gomics/analysis"
+)
+
+// PageFlag tells why an image is probably not part of the comic
+type PageFlag string
+
+const (
+	FlagNone      PageFlag = ""
+	FlagBlank     PageFlag = "blank"
+	FlagDuplicate PageFlag = "duplicate"
+)
+
+// analyzePages returns the flag of each image : blank, duplicate of a previous image or none
+func analyzePages(images []ImageData) ([]PageFlag, error) {
+	findings, err := analysis.Analyze(len(images), func(index int) (image.Image, error) {
+		return peekImage(&images[index])
+	}, analysis.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]PageFlag, len(images))
+	for _, finding := range findings {
+		if finding.Blank {
+			flags[finding.Index] = FlagBlank
+		} else {
+			flags[finding.Index] = FlagDuplicate
+		}
+	}
+	return flags, nil
+}
+
+// pageAnalysis is the result of the analysis of the images of the album, the images being identified by key
+// as they may have been edited during the analysis
+type pageAnalysis struct {
+	keys  []string
+	flags []PageFlag
+}
+
+// startPageAnalysis analyzes the images of the album in the background to flag the blank and duplicated pages
+func (g *GogoReader) startPageAnalysis() {
+	if g.pageAnalysisRunning {
+		return
+	}
+	g.pageAnalysisRunning = true
+	if g.pageAnalysisResult == nil {
+		g.pageAnalysisResult = make(chan *pageAnalysis, 1)
+	}
+	g.notify("Looking for blank and duplicated pages...")
+	// the goroutine reads copies, the images of the album being edited meanwhile
+	images := make([]ImageData, 0, len(album.Images))
+	keys := make([]string, 0, len(album.Images))
+	for _, img := range album.Images {
+		images = append(images, *img)
+		keys = append(keys, img.key())
+	}
+	go func() {
+		flags, err := analyzePages(images)
+		if err != nil {
+			g.notifyError(err)
+			g.pageAnalysisResult <- nil
+			return
+		}
+		g.pageAnalysisResult <- &pageAnalysis{keys: keys, flags: flags}
+	}()
+}
+
+// updatePageAnalysis flags the images once the analysis is done, and hides them when the preferences ask for it
+func (g *GogoReader) updatePageAnalysis() {
+	var result *pageAnalysis
+	select {
+	case result = <-g.pageAnalysisResult:
+		g.pageAnalysisRunning = false
+	default:
+		return
+	}
+	if result == nil {
+		return
+	}
+
+	var blank, duplicates int
+	g.record("analyze-pages", false, func() {
+		byKey := make(map[string]*ImageData, len(album.Images))
+		for _, img := range album.Images {
+			byKey[img.key()] = img
+		}
+		album.forEachImage(func(img *ImageData) { img.Flag = FlagNone })
+		for index, key := range result.keys {
+			flag := result.flags[index]
+			imgData, found := byKey[key]
+			if flag == FlagNone || !found {
+				// images split or merged during the analysis are left unflagged
+				continue
+			}
+			if flag == FlagBlank {
+				blank++
+			} else {
+				duplicates++
+			}
+			album.forEachImage(func(img *ImageData) {
+				if sameImage(img, imgData) {
+					img.Flag = flag
+				}
+			})
+			if g.preferences.AutoHidePages {
+				album.hideImage(imgData)
+			}
+		}
+	})
+
+	if g.preferences.AutoHidePages {
+		g.notify("%d blank and %d duplicated page(s) hidden", blank, duplicates)
+	} else {
+		g.notify("%d blank and %d duplicated page(s) found, see the thumbnails", blank, duplicates)
+	}
+	g.needsRefresh = true
+}
@@ -0,0 +1,195 @@
+package panels
+
+import (
+	"image"
+	"math"
+
+	"github.com/mozvip/gomics/sampling"
+)
+
+// Options controls the detection of the panels
+type Options struct {
+	// Threshold is the maximum difference (0.0..1.0) between the luminance of a gutter pixel and the gutter color
+	Threshold float64
+	// MaxNoise is the ratio of pixels of a gutter line allowed to differ from the gutter color
+	MaxNoise float64
+	// MinGutter is the minimum width of a gutter, in pixels
+	MinGutter int
+	// MinPanelSize is the minimum width and height of a panel, relative to the size of the page
+	MinPanelSize float64
+	// Step is the distance in pixels between the pixels compared along a line
+	Step int
+	// RightToLeft orders the panels of a row from right to left
+	RightToLeft bool
+}
+
+// DefaultOptions are the options used when reading comics from left to right
+var DefaultOptions = Options{
+	Threshold:    0.12,
+	MaxNoise:     0.01,
+	MinGutter:    4,
+	MinPanelSize: 0.08,
+	Step:         2,
+}
+
+// luminance returns the luminance of a pixel, in range 0.0..1.0
+func luminance(read sampling.Reader, x, y int) float64 {
+	r, g, b := read(x, y)
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+// gutterLuminance returns the luminance of the gutters : the page margins are
+// sampled, and the gutters are white unless most of the margins are dark
+func gutterLuminance(read sampling.Reader, rect image.Rectangle) float64 {
+	var sum, count float64
+	add := func(x, y int) {
+		sum += luminance(read, x, y)
+		count++
+	}
+	for x := rect.Min.X; x < rect.Max.X; x += 4 {
+		add(x, rect.Min.Y)
+		add(x, rect.Max.Y-1)
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y += 4 {
+		add(rect.Min.X, y)
+		add(rect.Max.X-1, y)
+	}
+	if count == 0 || sum/count > 0.5 {
+		return 1
+	}
+	return 0
+}
+
+type detector struct {
+	read    sampling.Reader
+	options Options
+	gutter  float64
+	// minimum size of a panel in pixels
+	minWidth, minHeight int
+}
+
+// isGutter returns true when the line from (x0, y0) to (x1, y1) (excluded), which is horizontal or vertical, has the gutter color
+func (d *detector) isGutter(x0, y0, x1, y1 int) bool {
+	dx, dy := 0, 0
+	length := 0
+	if y0 == y1 {
+		dx, length = d.options.Step, x1-x0
+	} else {
+		dy, length = d.options.Step, y1-y0
+	}
+	maxBad := int(float64(length/d.options.Step) * d.options.MaxNoise)
+	bad := 0
+	for x, y := x0, y0; x < x1 || y < y1; x, y = x+dx, y+dy {
+		if math.Abs(luminance(d.read, x, y)-d.gutter) > d.options.Threshold {
+			bad++
+			if bad > maxBad {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segments returns the parts of [min, max) separated by gutters, gutter(i) telling if line i is a gutter
+func (d *detector) segments(min, max, minSize int, gutter func(i int) bool) [][2]int {
+	var segments [][2]int
+	start := -1
+	gutterWidth := 0
+	for i := min; i < max; i++ {
+		if gutter(i) {
+			gutterWidth++
+			if start >= 0 && gutterWidth >= d.options.MinGutter {
+				if end := i - gutterWidth + 1; end-start >= minSize {
+					segments = append(segments, [2]int{start, end})
+				}
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		gutterWidth = 0
+	}
+	if start >= 0 && max-start >= minSize {
+		segments = append(segments, [2]int{start, max})
+	}
+	return segments
+}
+
+// rows splits a rectangle along its horizontal gutters
+func (d *detector) rows(rect image.Rectangle) []image.Rectangle {
+	var rows []image.Rectangle
+	for _, segment := range d.segments(rect.Min.Y, rect.Max.Y, d.minHeight, func(y int) bool {
+		return d.isGutter(rect.Min.X, y, rect.Max.X, y)
+	}) {
+		rows = append(rows, image.Rect(rect.Min.X, segment[0], rect.Max.X, segment[1]))
+	}
+	return rows
+}
+
+// columns splits a rectangle along its vertical gutters, in reading order
+func (d *detector) columns(rect image.Rectangle) []image.Rectangle {
+	var columns []image.Rectangle
+	for _, segment := range d.segments(rect.Min.X, rect.Max.X, d.minWidth, func(x int) bool {
+		return d.isGutter(x, rect.Min.Y, x, rect.Max.Y)
+	}) {
+		columns = append(columns, image.Rect(segment[0], rect.Min.Y, segment[1], rect.Max.Y))
+	}
+	if d.options.RightToLeft {
+		for i, j := 0, len(columns)-1; i < j; i, j = i+1, j-1 {
+			columns[i], columns[j] = columns[j], columns[i]
+		}
+	}
+	return columns
+}
+
+// split cuts a rectangle in rows then columns recursively, until no gutter is left
+func (d *detector) split(rect image.Rectangle, depth int) []image.Rectangle {
+	rows := d.rows(rect)
+	if len(rows) == 0 {
+		return nil
+	}
+	var panels []image.Rectangle
+	for _, row := range rows {
+		columns := d.columns(row)
+		if len(columns) == 1 && len(rows) == 1 {
+			// no more gutter, the rectangle is a panel once its margins are removed
+			return d.rows(columns[0])
+		}
+		for _, column := range columns {
+			if depth > 8 {
+				panels = append(panels, column)
+			} else {
+				panels = append(panels, d.split(column, depth+1)...)
+			}
+		}
+	}
+	return panels
+}
+
+// Detect returns the panels of the rect part of an image, in reading order : rows
+// from top to bottom, and panels of a row from left to right (or right to left).
+// The whole rect is returned as a single panel when no gutter is found.
+func Detect(img image.Image, rect image.Rectangle, options Options) []image.Rectangle {
+	rect = rect.Intersect(img.Bounds())
+	if rect.Empty() {
+		return nil
+	}
+	if options.Step < 1 {
+		options.Step = 1
+	}
+	read := sampling.NewReader(img)
+	d := &detector{
+		read:      read,
+		options:   options,
+		gutter:    gutterLuminance(read, rect),
+		minWidth:  int(float64(rect.Dx()) * options.MinPanelSize),
+		minHeight: int(float64(rect.Dy()) * options.MinPanelSize),
+	}
+	panels := d.split(rect, 0)
+	if len(panels) == 0 {
+		return []image.Rectangle{rect}
+	}
+	return panels
+}
@@ -0,0 +1,85 @@
+package panels
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"reflect"
+	"testing"
+)
+
+// page draws panels of the given color on a page of the background color
+func page(background, ink uint8, panels ...image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 200, 300))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: background}), image.Point{}, draw.Src)
+	for _, panel := range panels {
+		draw.Draw(img, panel, image.NewUniform(color.Gray{Y: ink}), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+var (
+	top         = image.Rect(10, 10, 190, 100)
+	bottomLeft  = image.Rect(10, 110, 95, 290)
+	bottomRight = image.Rect(105, 110, 190, 290)
+)
+
+func TestDetectReadingOrder(t *testing.T) {
+	img := page(255, 40, top, bottomLeft, bottomRight)
+
+	panels := Detect(img, img.Bounds(), DefaultOptions)
+	if want := []image.Rectangle{top, bottomLeft, bottomRight}; !reflect.DeepEqual(panels, want) {
+		t.Errorf("left to right: got %v, want %v", panels, want)
+	}
+
+	options := DefaultOptions
+	options.RightToLeft = true
+	panels = Detect(img, img.Bounds(), options)
+	if want := []image.Rectangle{top, bottomRight, bottomLeft}; !reflect.DeepEqual(panels, want) {
+		t.Errorf("right to left: got %v, want %v", panels, want)
+	}
+}
+
+func TestDetectDarkGutters(t *testing.T) {
+	img := page(0, 230, top, bottomLeft, bottomRight)
+
+	panels := Detect(img, img.Bounds(), DefaultOptions)
+	if want := []image.Rectangle{top, bottomLeft, bottomRight}; !reflect.DeepEqual(panels, want) {
+		t.Errorf("got %v, want %v", panels, want)
+	}
+}
+
+func TestDetectInsideRect(t *testing.T) {
+	img := page(255, 40, top, bottomLeft, bottomRight)
+
+	// only the bottom row is analyzed, the top panel must be ignored
+	rect := image.Rect(0, 105, 200, 300)
+	panels := Detect(img, rect, DefaultOptions)
+	if want := []image.Rectangle{bottomLeft, bottomRight}; !reflect.DeepEqual(panels, want) {
+		t.Errorf("got %v, want %v", panels, want)
+	}
+}
+
+func TestDetectWithoutGutter(t *testing.T) {
+	img := page(40, 40)
+
+	panels := Detect(img, img.Bounds(), DefaultOptions)
+	if want := []image.Rectangle{img.Bounds()}; !reflect.DeepEqual(panels, want) {
+		t.Errorf("got %v, want the whole page %v", panels, want)
+	}
+
+	if panels := Detect(img, image.Rect(300, 400, 350, 450), DefaultOptions); panels != nil {
+		t.Errorf("got %v for a rect outside of the image, want nil", panels)
+	}
+}
+
+func TestDetectIgnoresSmallPanels(t *testing.T) {
+	// a 6 pixels wide box is smaller than MinPanelSize and must not be split off
+	box := image.Rect(100, 190, 106, 196)
+	img := page(255, 40, top, box)
+
+	panels := Detect(img, img.Bounds(), DefaultOptions)
+	if len(panels) != 1 || panels[0] != top {
+		t.Errorf("got %v, want only %v", panels, top)
+	}
+}
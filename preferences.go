@@ -4,28 +4,111 @@ import (
 	"path"
 
 	"github.com/faiface/pixel"
+	"github.com/mozvip/gomics/crop"
+	"github.com/mozvip/gomics/filters"
+	"github.com/mozvip/gomics/thumbnails"
 )
 
 type ImageFilter uint
 
 const (
-	LANCZOS          ImageFilter = 0
-	NEAREST_NEIGHBOR ImageFilter = 1
+	LANCZOS            ImageFilter = 0
+	NEAREST_NEIGHBOR   ImageFilter = 1
+	CATMULL_ROM        ImageFilter = 2
+	MITCHELL_NETRAVALI ImageFilter = 3
+	LINEAR             ImageFilter = 4
+	BOX                ImageFilter = 5
+	GAUSSIAN           ImageFilter = 6
 )
 
+// ClickZones are the actions run when clicking on the left, center and right parts of the window.
+// In right to left mode, the left and right zones are swapped.
+type ClickZones struct {
+	Enabled bool
+	// SideWidth is the width of the left and right zones, as a fraction of the window width
+	SideWidth float64
+	Left      string
+	Center    string
+	Right     string
+}
+
 type Preferences struct {
 	FullScreen    bool
 	RemoveBorders bool
-	Filter        ImageFilter
-	WindowedSize  pixel.Vec
+	// Filter is used to scale the images down to the size of the window
+	Filter       ImageFilter
+	WindowedSize pixel.Vec
+
+	// maximum size of the thumbnail cache, in megabytes
+	ThumbnailCacheSize int64
+
+	// analog sticks values below the dead zone (0 to 1) are ignored
+	GamepadDeadzone float64
+	// GamepadPanStick is the analog stick used to move around a zoomed page : left or right
+	GamepadPanStick string
+	// GamepadPanSpeed is the number of pixels a page moves at each frame when the stick is fully pushed
+	GamepadPanSpeed float64
+
+	// ClickZones are used by left clicks, the whole window runs the center action when they are disabled
+	ClickZones ClickZones
+	// SwipeDistance is the horizontal distance, as a fraction of the window width, of a mouse drag turning the page, 0 to disable
+	SwipeDistance float64
+
+	// SlideshowInterval is the number of seconds each page is displayed during a slideshow
+	SlideshowInterval float64
+	// SlideshowAutoScroll scrolls the pages taller than the window from top to bottom during a slideshow
+	SlideshowAutoScroll bool
+
+	// Transition is the animation used when changing pages : none, fade, slide or curl
+	Transition string
+	// TransitionDuration is the duration of the page transitions, in seconds
+	TransitionDuration float64
+
+	// ColorVision is the color vision deficiency the colors of the images are corrected for : protan, deutan or tritan
+	ColorVision filters.Deficiency `yaml:",omitempty"`
+	// ColorVisionSimulation shows the images as they are seen with the deficiency instead of correcting them
+	ColorVisionSimulation bool
+
+	// SpreadOverlap is the part of the width of a spread each of its pages extends past the fold when it is split
+	SpreadOverlap float64
+
+	// AutoHidePages hides the blank and duplicated pages found by the page analysis instead of only flagging them
+	AutoHidePages bool
+
+	// HistoryDepth is the number of edits of an album which can be undone, SaveHistory keeps them when the album is closed
+	HistoryDepth int
+	SaveHistory  bool
+
+	// Crop controls the automatic border removal, albums can override it
+	Crop crop.Options
+
+	// KeyBindings replaces the default bindings of the actions, by action name
+	KeyBindings map[string][]string `yaml:",omitempty"`
 }
 
 func NewPreferences() Preferences {
 	preferences := Preferences{}
 	preferences.Filter = LANCZOS
+	preferences.ThumbnailCacheSize = 256
+	preferences.GamepadDeadzone = 0.25
+	preferences.GamepadPanStick = "left"
+	preferences.GamepadPanSpeed = 20
+	preferences.ClickZones = ClickZones{Enabled: true, SideWidth: 1.0 / 3, Left: "previous-page", Center: "zoom", Right: "next-page"}
+	preferences.SwipeDistance = 0.15
+	preferences.SlideshowInterval = 5
+	preferences.SlideshowAutoScroll = true
+	preferences.Transition = TransitionNone
+	preferences.TransitionDuration = 0.3
+	preferences.SpreadOverlap = 0.01
+	preferences.HistoryDepth = 50
+	preferences.Crop = crop.DefaultOptions
 	return preferences
 }
 
 func getGlobalConfigurationFile() string {
 	return path.Join(configFolder, "config.yml")
 }
+
+func newThumbnailCache(preferences Preferences) *thumbnails.Cache {
+	return thumbnails.NewCache(path.Join(configFolder, "thumbnails"), preferences.ThumbnailCacheSize*1024*1024)
+}
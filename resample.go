@@ -0,0 +1,142 @@
+package main
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/faiface/pixel"
+)
+
+// the images are scaled again once the window size has not changed for this delay
+const resizeDelay = 300 * time.Millisecond
+
+var resampleFilters = []struct {
+	name   string
+	filter imaging.ResampleFilter
+}{
+	LANCZOS:            {"Lanczos", imaging.Lanczos},
+	NEAREST_NEIGHBOR:   {"nearest neighbor", imaging.NearestNeighbor},
+	CATMULL_ROM:        {"Catmull-Rom", imaging.CatmullRom},
+	MITCHELL_NETRAVALI: {"Mitchell-Netravali", imaging.MitchellNetravali},
+	LINEAR:             {"linear", imaging.Linear},
+	BOX:                {"box", imaging.Box},
+	GAUSSIAN:           {"Gaussian", imaging.Gaussian},
+}
+
+func (f ImageFilter) String() string {
+	if int(f) < len(resampleFilters) {
+		return resampleFilters[f].name
+	}
+	return resampleFilters[LANCZOS].name
+}
+
+// ResampleFilter returns the imaging filter, Lanczos for unknown filters
+func (f ImageFilter) ResampleFilter() imaging.ResampleFilter {
+	if int(f) < len(resampleFilters) {
+		return resampleFilters[f].filter
+	}
+	return imaging.Lanczos
+}
+
+// Next returns the filter following f, used to cycle through the filters
+func (f ImageFilter) Next() ImageFilter {
+	return ImageFilter((int(f) + 1) % len(resampleFilters))
+}
+
+// prescaleSize returns the size of the window the views are scaled for, zero when they are displayed
+// at full resolution because the guided view zooms on the panels
+func (g *GogoReader) prescaleSize() pixel.Vec {
+	if g.guided.active {
+		return pixel.ZV
+	}
+	return g.size
+}
+
+// prescale returns the scale used to reduce the images of a view to the size they are displayed at :
+// the view fits the height of the window, or its width when zoomed. Images are never enlarged.
+func prescale(size pixel.Vec, totalWidth, maxHeight float64) float64 {
+	if size == pixel.ZV || totalWidth == 0 || maxHeight == 0 {
+		return 1
+	}
+	return math.Min(1, math.Max(size.Y/maxHeight, size.X/totalWidth))
+}
+
+// resampleImage scales down the rect part of an image
+func resampleImage(img image.Image, rect image.Rectangle, scale float64, filter imaging.ResampleFilter) *pixel.PictureData {
+	cropped := imaging.Crop(img, rect)
+	width := int(math.Max(1, math.Round(float64(rect.Dx())*scale)))
+	height := int(math.Max(1, math.Round(float64(rect.Dy())*scale)))
+	return pixel.PictureDataFromImage(imaging.Resize(cropped, width, height, filter))
+}
+
+// resampledView holds the sprites of a view scaled down in the background
+type resampledView struct {
+	view       *ViewData
+	generation int
+	sprites    []*pixel.Sprite
+	scales     []float64
+}
+
+// resampleLater scales down the images of a view in the background, the sprites of the view are replaced
+// by updateResampled once they are ready. The view must be locked.
+func (g *GogoReader) resampleLater(view *ViewData, rawImages []image.Image, cropRects []image.Rectangle, scale float64) {
+	if g.resampled == nil {
+		g.resampled = make(chan *resampledView, 16)
+	}
+	result := &resampledView{view: view, generation: view.generation}
+	frames := append([]pixel.Rect(nil), view.imageFrames...)
+	filter := g.preferences.Filter.ResampleFilter()
+	go func() {
+		for index, frame := range frames {
+			pictureData := resampleImage(rawImages[index], cropRects[index], scale, filter)
+			result.sprites = append(result.sprites, pixel.NewSprite(pictureData, pictureData.Bounds()))
+			result.scales = append(result.scales, pictureData.Bounds().W()/frame.W())
+		}
+		g.resampled <- result
+	}()
+}
+
+// updateResampled replaces the sprites of the views scaled down in the background
+func (g *GogoReader) updateResampled() {
+	for {
+		select {
+		case result := <-g.resampled:
+			result.view.mu.Lock()
+			if result.generation == result.view.generation {
+				result.view.imageSprites = result.sprites
+				result.view.spriteScales = result.scales
+			}
+			result.view.mu.Unlock()
+		default:
+			return
+		}
+	}
+}
+
+// sameFrames returns true when the images of a view keep the same frames, their previous sprites can be drawn
+func sameFrames(a, b []pixel.Rect) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkResize scales the images again when the size of the window changed
+func (g *GogoReader) checkResize() {
+	if size := g.win.Bounds().Max; size != g.size {
+		g.size = size
+		g.resizedAt = time.Now()
+		return
+	}
+	if !g.resizedAt.IsZero() && time.Since(g.resizedAt) > resizeDelay {
+		g.resizedAt = time.Time{}
+		g.needsRefresh = true
+	}
+}
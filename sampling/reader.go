@@ -0,0 +1,64 @@
+// Package sampling reads the colors of the pixels of images, with fast paths for the common image types
+package sampling
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/faiface/pixel"
+)
+
+// Reader returns the color of a pixel, components being in range 0.0..1.0
+type Reader func(x, y int) (r, g, b float64)
+
+// NewReader returns a reader of the pixels of an image, in image coordinates (from the top left corner)
+func NewReader(img image.Image) Reader {
+	switch img := img.(type) {
+	case *image.RGBA:
+		return func(x, y int) (r, g, b float64) {
+			if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+				return 0, 0, 0
+			}
+			i := img.PixOffset(x, y)
+			return float64(img.Pix[i]) / 255, float64(img.Pix[i+1]) / 255, float64(img.Pix[i+2]) / 255
+		}
+	case *image.NRGBA:
+		return func(x, y int) (r, g, b float64) {
+			if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+				return 0, 0, 0
+			}
+			i := img.PixOffset(x, y)
+			a := float64(img.Pix[i+3]) / (255 * 255)
+			return float64(img.Pix[i]) * a, float64(img.Pix[i+1]) * a, float64(img.Pix[i+2]) * a
+		}
+	case *image.Gray:
+		return func(x, y int) (r, g, b float64) {
+			if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+				return 0, 0, 0
+			}
+			v := float64(img.Pix[img.PixOffset(x, y)]) / 255
+			return v, v, v
+		}
+	case *image.YCbCr:
+		return func(x, y int) (r, g, b float64) {
+			if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+				return 0, 0, 0
+			}
+			yi, ci := img.YOffset(x, y), img.COffset(x, y)
+			r8, g8, b8 := color.YCbCrToRGB(img.Y[yi], img.Cb[ci], img.Cr[ci])
+			return float64(r8) / 255, float64(g8) / 255, float64(b8) / 255
+		}
+	}
+	return func(x, y int) (r, g, b float64) {
+		r32, g32, b32, _ := img.At(x, y).RGBA()
+		return float64(r32) / 0xffff, float64(g32) / 0xffff, float64(b32) / 0xffff
+	}
+}
+
+// NewPictureReader returns a reader of the pixels of a picture, in picture coordinates (from the bottom left corner)
+func NewPictureReader(pictureData *pixel.PictureData) Reader {
+	return func(x, y int) (r, g, b float64) {
+		c := pictureData.Color(pixel.V(float64(x), float64(y)))
+		return c.R, c.G, c.B
+	}
+}
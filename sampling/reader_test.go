@@ -0,0 +1,90 @@
+package sampling
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// pattern returns the color of a pixel of the test images, varying on both axes
+func pattern(x, y int) color.RGBA {
+	return color.RGBA{R: uint8(x * 17), G: uint8(y * 29), B: uint8((x + y) * 7), A: 255}
+}
+
+func fill(img interface {
+	image.Image
+	Set(x, y int, c color.Color)
+}) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.Set(x, y, pattern(x, y))
+		}
+	}
+}
+
+func newYCbCr(rect image.Rectangle, ratio image.YCbCrSubsampleRatio) *image.YCbCr {
+	img := image.NewYCbCr(rect, ratio)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := pattern(x, y)
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			img.Y[img.YOffset(x, y)] = yy
+			img.Cb[img.COffset(x, y)] = cb
+			img.Cr[img.COffset(x, y)] = cr
+		}
+	}
+	return img
+}
+
+func TestNewReader(t *testing.T) {
+	rect := image.Rect(3, 5, 19, 17)
+	rgba := image.NewRGBA(rect)
+	fill(rgba)
+	nrgba := image.NewNRGBA(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			c := pattern(x, y)
+			nrgba.SetNRGBA(x, y, color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(x * 13)})
+		}
+	}
+	gray := image.NewGray(rect)
+	fill(gray)
+	paletted := image.NewPaletted(rect, color.Palette{color.Black, color.White, color.RGBA{R: 200, G: 100, B: 50, A: 255}})
+	fill(paletted)
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"RGBA", rgba},
+		{"NRGBA", nrgba},
+		{"Gray", gray},
+		{"YCbCr 4:4:4", newYCbCr(rect, image.YCbCrSubsampleRatio444)},
+		{"YCbCr 4:2:0", newYCbCr(rect, image.YCbCrSubsampleRatio420)},
+		{"Paletted", paletted},
+	}
+	// the conversions of the fast paths round the components to 8 bits
+	const tolerance = 1.0 / 255
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			read := NewReader(test.img)
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					r32, g32, b32, _ := test.img.At(x, y).RGBA()
+					r, g, b := read(x, y)
+					if math.Abs(r-float64(r32)/0xffff) > tolerance || math.Abs(g-float64(g32)/0xffff) > tolerance || math.Abs(b-float64(b32)/0xffff) > tolerance {
+						t.Fatalf("read(%d, %d) = (%.3f, %.3f, %.3f), want (%.3f, %.3f, %.3f)", x, y, r, g, b,
+							float64(r32)/0xffff, float64(g32)/0xffff, float64(b32)/0xffff)
+					}
+				}
+			}
+			for _, p := range []image.Point{{rect.Min.X - 1, rect.Min.Y}, {rect.Max.X, rect.Max.Y - 1}, {rect.Min.X, rect.Max.Y}} {
+				if r, g, b := read(p.X, p.Y); r != 0 || g != 0 || b != 0 {
+					t.Errorf("read(%d, %d) = (%.3f, %.3f, %.3f) outside of the image, want black", p.X, p.Y, r, g, b)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"image"
+
+	"github.com/mozvip/gomics/deskew"
+)
+
+// angle returns the rotation applied to the view : the angle set by the user or, when the automatic deskew
+// of the album is on, the angle proposed by the skew detection
+func (v *ViewData) angle() float64 {
+	if album.AutoDeskew && !v.AngleSet && v.SkewDetected {
+		return v.SkewAngle
+	}
+	return v.RotationAngle
+}
+
+// setAngle overrides the rotation of the view
+func (v *ViewData) setAngle(angle float64) {
+	v.RotationAngle = angle
+	v.AngleSet = true
+	v.ClearPanels()
+}
+
+// detectSkew proposes the rotation straightening the view, from its first image before any rotation
+func (v *ViewData) detectSkew(img image.Image) {
+	v.SkewAngle = deskew.Detect(img, deskew.DefaultOptions)
+	v.SkewDetected = true
+}
+
+// acceptSkew rotates the current view by the angle proposed by the skew detection, detecting it first if needed
+func (g *GogoReader) acceptSkew() {
+	view := album.GetCurrentView()
+	if !view.SkewDetected {
+		img, err := readImage(view.Images[0])
+		if err != nil {
+			g.notifyError(err)
+			return
+		}
+		view.detectSkew(img)
+	}
+	view.setAngle(view.SkewAngle)
+	g.notify("Page rotated by %.2f degrees", view.SkewAngle)
+	g.needsRefresh = true
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// slideshow turns the pages automatically, scrolling down the pages taller than the window
+type slideshow struct {
+	running bool
+	// viewIndex is the view the timer was started for
+	viewIndex int
+	// elapsed is the time spent on the current view, pauses excluded
+	elapsed    time.Duration
+	duration   time.Duration
+	lastUpdate time.Time
+	// the slideshow is paused for an interval after each user input
+	pausedUntil time.Time
+	ignoreInput bool
+	// scrolling is true when the current view is zoomed and scrolled from top to bottom
+	scrolling bool
+}
+
+func (g *GogoReader) toggleSlideshow() {
+	g.slideshow.running = !g.slideshow.running
+	if g.slideshow.running {
+		// the key starting the slideshow does not pause it
+		g.slideshow.ignoreInput = true
+		g.slideshow.pausedUntil = time.Time{}
+		g.restartSlideshowPage()
+		g.notify("Slideshow started, every %.0f seconds", g.preferences.SlideshowInterval)
+	} else {
+		g.stopSlideshowScrolling()
+		g.notify("Slideshow stopped")
+	}
+}
+
+func (g *GogoReader) stopSlideshowScrolling() {
+	if g.slideshow.scrolling {
+		g.slideshow.scrolling = false
+		g.Zoom = false
+	}
+}
+
+// zoomTranslates returns true when the view zoomed to the width of the window keeps its size : scaling it around
+// the zoom position would not move it, so the view is moved by the distance between the zoom position and the center
+func zoomTranslates(scale float64) bool {
+	return math.Abs(1-scale) < 1e-3
+}
+
+// zoomLimits returns the zoom positions displaying the top and the bottom of the current view
+// when it is zoomed to the width of the window, ok is false if the zoomed view fits in the window
+func (g *GogoReader) zoomLimits() (top float64, bottom float64, ok bool) {
+	view := album.GetCurrentView()
+	if view.totalWidth == 0 {
+		return 0, 0, false
+	}
+	scale := g.size.X / view.totalWidth
+	if view.maxHeight*scale <= g.size.Y {
+		return 0, 0, false
+	}
+	center := g.size.Y / 2
+	if zoomTranslates(scale) {
+		return view.maxHeight / 2, g.size.Y - view.maxHeight/2, true
+	}
+	top = (g.size.Y - scale*(center+view.maxHeight/2)) / (1 - scale)
+	bottom = scale * (view.maxHeight/2 - center) / (1 - scale)
+	return top, bottom, true
+}
+
+// restartSlideshowPage starts the timer of the current view, and its scrolling if it is too tall for the window
+func (g *GogoReader) restartSlideshowPage() {
+	g.slideshow.viewIndex = album.CurrentViewIndex
+	g.slideshow.elapsed = 0
+	g.slideshow.lastUpdate = time.Now()
+	g.slideshow.duration = time.Duration(g.preferences.SlideshowInterval * float64(time.Second))
+
+	g.stopSlideshowScrolling()
+	if !g.preferences.SlideshowAutoScroll {
+		return
+	}
+	if top, _, ok := g.zoomLimits(); ok {
+		view := album.GetCurrentView()
+		g.slideshow.scrolling = true
+		g.Zoom = true
+		g.ZoomPositionX = g.win.Bounds().Center().X
+		g.ZoomPositionY = top
+		// the taller the page, the longer it takes to read it
+		screens := view.maxHeight * (g.size.X / view.totalWidth) / g.size.Y
+		g.slideshow.duration = time.Duration(float64(g.slideshow.duration) * screens)
+	}
+}
+
+// userInput returns true when a key, a mouse button, the mouse wheel or a gamepad was used during the last update
+func (g *GogoReader) userInput() bool {
+	for button := pixelgl.MouseButton1; button <= pixelgl.KeyLast; button++ {
+		if g.win.JustPressed(button) {
+			return true
+		}
+	}
+	if g.win.MouseScroll().Y != 0 {
+		return true
+	}
+	for _, joystick := range connectedGamepads(g.win) {
+		for button := pixelgl.ButtonA; button <= pixelgl.ButtonLast; button++ {
+			if g.win.JoystickJustPressed(joystick, button) {
+				return true
+			}
+		}
+	}
+	return g.gamepadAxis(pixelgl.AxisLeftY) != 0 || g.gamepadAxis(pixelgl.AxisRightY) != 0
+}
+
+func (g *GogoReader) updateSlideshow() {
+	if !g.slideshow.running {
+		return
+	}
+
+	if album.CurrentViewIndex != g.slideshow.viewIndex {
+		g.restartSlideshowPage()
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(g.slideshow.lastUpdate)
+	g.slideshow.lastUpdate = now
+
+	if g.userInput() && !g.slideshow.ignoreInput {
+		// the user keeps control of the current page for a full interval after the last input
+		g.slideshow.pausedUntil = now.Add(time.Duration(g.preferences.SlideshowInterval * float64(time.Second)))
+		if g.slideshow.scrolling && !g.Zoom {
+			// zoom was turned off by the user
+			g.slideshow.scrolling = false
+		}
+	}
+	g.slideshow.ignoreInput = false
+	if now.Before(g.slideshow.pausedUntil) {
+		return
+	}
+
+	g.slideshow.elapsed += elapsed
+	if g.slideshow.scrolling {
+		if top, bottom, ok := g.zoomLimits(); ok {
+			progress := math.Min(1, float64(g.slideshow.elapsed)/float64(g.slideshow.duration))
+			g.ZoomPositionY = top + (bottom-top)*progress
+		}
+	}
+
+	if g.slideshow.elapsed < g.slideshow.duration {
+		return
+	}
+	if album.CurrentViewIndex >= len(album.Views)-1 {
+		g.slideshow.running = false
+		g.stopSlideshowScrolling()
+		g.notify("Slideshow stopped, end of the album")
+		return
+	}
+	g.NextPage()
+}
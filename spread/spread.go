@@ -0,0 +1,68 @@
+// Package spread finds the fold of the scans of two facing pages
+package spread
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// Options controls the detection of the gutter
+type Options struct {
+	// Search is the distance from the center of the image, relative to its width, where the gutter is searched
+	Search float64
+	// Width is the width the image is reduced to before the detection, in pixels
+	Width int
+}
+
+// DefaultOptions are the options used by the reader
+var DefaultOptions = Options{
+	Search: 0.1,
+	Width:  400,
+}
+
+// DetectGutter returns the position of the fold between the two pages of a spread, relative to the width of the image.
+// The fold is the most uniform column near the center, either the blank margins of the pages or the shadow of the binding;
+// the center is returned when the artwork crosses the fold.
+func DetectGutter(img image.Image, options Options) float64 {
+	width := options.Width
+	if img.Bounds().Dx() < width {
+		width = img.Bounds().Dx()
+	}
+	if width < 3 {
+		return 0.5
+	}
+	small := imaging.Grayscale(imaging.Resize(img, width, 0, imaging.Box))
+	w, h := small.Bounds().Dx(), small.Bounds().Dy()
+	if h == 0 {
+		return 0.5
+	}
+
+	// standard deviation of the luminance of each column
+	deviations := make([]float64, w)
+	for x := 0; x < w; x++ {
+		var sum, squares float64
+		for y := 0; y < h; y++ {
+			v := float64(small.Pix[small.PixOffset(x, y)]) / 255
+			sum += v
+			squares += v * v
+		}
+		mean := sum / float64(h)
+		deviations[x] = math.Sqrt(math.Max(0, squares/float64(h)-mean*mean))
+	}
+
+	center := float64(w) / 2
+	from := int(math.Max(1, center-options.Search*float64(w)))
+	to := int(math.Min(float64(w-2), center+options.Search*float64(w)))
+	best, bestScore := center, math.MaxFloat64
+	for x := from; x <= to; x++ {
+		// the columns around smooth the noise of the scan, the distance to the center breaks the ties
+		deviation := (deviations[x-1] + deviations[x] + deviations[x+1]) / 3
+		score := deviation + 0.1*math.Abs(float64(x)+0.5-center)/float64(w)
+		if score < bestScore {
+			best, bestScore = float64(x)+0.5, score
+		}
+	}
+	return best / float64(w)
+}
@@ -0,0 +1,56 @@
+package spread
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// spread returns a scan of two pages covered with lines of text, the fold being a band of the given color
+func spread(width, height int, fold, foldWidth float64, foldColor uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	from, to := (fold-foldWidth/2)*float64(width), (fold+foldWidth/2)*float64(width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch {
+			case float64(x) >= from && float64(x) < to:
+				img.SetGray(x, y, color.Gray{Y: foldColor})
+			case y%12 < 4:
+				img.SetGray(x, y, color.Gray{Y: 30})
+			default:
+				img.SetGray(x, y, color.Gray{Y: 240})
+			}
+		}
+	}
+	return img
+}
+
+func TestDetectGutter(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		fold          float64
+		foldWidth     float64
+		foldColor     uint8
+		// any position inside the band of the fold is right
+		want float64
+		band float64
+	}{
+		{"blank margins at the center", 800, 600, 0.5, 0.04, 240, 0.5, 0.02},
+		{"blank margins left of the center", 800, 600, 0.45, 0.03, 240, 0.45, 0.015},
+		{"shadow of the binding right of the center", 1200, 500, 0.56, 0.02, 90, 0.56, 0.01},
+		{"image narrower than the reduction", 300, 200, 0.47, 0.04, 240, 0.47, 0.02},
+		{"fold outside of the search", 800, 600, 0.8, 0.04, 240, 0.5, 0.01},
+		{"artwork across the fold", 800, 600, 0.5, 0, 0, 0.5, 0.01},
+		{"tiny image", 2, 10, 0.5, 0, 0, 0.5, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := spread(test.width, test.height, test.fold, test.foldWidth, test.foldColor)
+			if got := DetectGutter(img, DefaultOptions); math.Abs(got-test.want) > test.band {
+				t.Errorf("DetectGutter() = %.3f, want %.3f ± %.3f", got, test.want, test.band)
+			}
+		})
+	}
+}
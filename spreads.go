@@ -0,0 +1,205 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/mozvip/gomics/spread"
+)
+
+// Half is the page of a spread shown by a virtual image
+type Half string
+
+const (
+	HalfNone  Half = ""
+	HalfLeft  Half = "left"
+	HalfRight Half = "right"
+)
+
+const (
+	overlapStep = 0.005
+	maxOverlap  = 0.25
+)
+
+// halfRect returns the part of the spread shown by the image, bounds being the bounds of the whole rotated entry
+func (i *ImageData) halfRect(bounds image.Rectangle) image.Rectangle {
+	gutter := bounds.Min.X + int(math.Round(i.Gutter*float64(bounds.Dx())))
+	overlap := int(math.Round(i.Overlap * float64(bounds.Dx())))
+	if i.Half == HalfLeft {
+		return image.Rect(bounds.Min.X, bounds.Min.Y, gutter+overlap, bounds.Max.Y).Intersect(bounds)
+	}
+	return image.Rect(gutter-overlap, bounds.Min.Y, bounds.Max.X, bounds.Max.Y).Intersect(bounds)
+}
+
+// sameImage returns true when two image data show the same part of the same archive entry
+func sameImage(a, b *ImageData) bool {
+	return a.FileName == b.FileName && a.Half == b.Half
+}
+
+// replaceImage replaces an image of the album by others, in reading order
+func (a *Album) replaceImage(old *ImageData, images ...*ImageData) {
+	for index, img := range a.Images {
+		if sameImage(img, old) {
+			a.Images = append(a.Images[:index], append(images, a.Images[index+1:]...)...)
+			return
+		}
+	}
+}
+
+// halves returns the images of the album showing a half of a spread, in the views and in the images of the album
+func (a *Album) halves(fileName string) []*ImageData {
+	var result []*ImageData
+	for _, view := range a.Views {
+		for _, img := range view.Images {
+			if img.FileName == fileName && img.Half != HalfNone {
+				result = append(result, img)
+			}
+		}
+	}
+	for _, img := range a.Images {
+		if img.FileName == fileName && img.Half != HalfNone {
+			result = append(result, img)
+		}
+	}
+	return result
+}
+
+// toggleSpread splits the image of the current view into its two pages, or merges back a split spread
+func (g *GogoReader) toggleSpread() {
+	view := album.GetCurrentView()
+	for _, imgData := range view.Images {
+		if imgData.Half != HalfNone {
+			g.mergeSpread(imgData)
+			return
+		}
+	}
+	if len(view.Images) != 1 {
+		g.notify("Only a page made of a single image can be split")
+		return
+	}
+	g.splitSpread(view)
+}
+
+// splitSpread replaces the image of a view by two virtual images, one for each page of the spread,
+// the second page being moved to a new view
+func (g *GogoReader) splitSpread(view *ViewData) {
+	spreadImage := view.Images[0]
+	img, err := readImage(spreadImage)
+	if err != nil {
+		g.notifyError(err)
+		return
+	}
+	gutter := spread.DetectGutter(img, spread.DefaultOptions)
+
+	left, right := *spreadImage, *spreadImage
+	left.Half, right.Half = HalfLeft, HalfRight
+	// the halves are cut from the rotated spread, and can then be rotated on their own
+	left.SpreadRotation, right.SpreadRotation = spreadImage.Rotation, spreadImage.Rotation
+	left.Rotation, right.Rotation = None, None
+	left.Gutter, right.Gutter = gutter, gutter
+	left.Overlap, right.Overlap = g.preferences.SpreadOverlap, g.preferences.SpreadOverlap
+	// the crop of the spread is kept on the outer sides of the pages
+	left.Right, right.Left = 0, 0
+	left.Panels, right.Panels = nil, nil
+	left.PanelsEdited, right.PanelsEdited = false, false
+
+	first, second := &left, &right
+	if album.RightToLeft {
+		first, second = second, first
+	}
+	album.replaceImage(spreadImage, first, second)
+	view.Images = []*ImageData{first}
+	view.SkewDetected = false
+	view.ClearPanels()
+	newView := &ViewData{Images: []*ImageData{second}, RotationAngle: view.RotationAngle, AngleSet: view.AngleSet, RemoveBorders: view.RemoveBorders}
+	album.Views = append(album.Views[:album.CurrentViewIndex+1], append([]*ViewData{newView}, album.Views[album.CurrentViewIndex+1:]...)...)
+
+	g.notify("Spread split at %.0f%% of its width", gutter*100)
+	g.needsRefresh = true
+}
+
+// mergeSpread replaces the two halves of a split spread by the whole image
+func (g *GogoReader) mergeSpread(half *ImageData) {
+	merged := *half
+	merged.Half, merged.Gutter, merged.Overlap = HalfNone, 0, 0
+	merged.Rotation, merged.SpreadRotation = half.SpreadRotation, None
+	merged.Panels, merged.PanelsEdited = nil, false
+
+	// the other half is removed from its view, and the view if it becomes empty
+	for viewIndex := 0; viewIndex < len(album.Views); viewIndex++ {
+		view := album.Views[viewIndex]
+		for imageIndex := 0; imageIndex < len(view.Images); imageIndex++ {
+			img := view.Images[imageIndex]
+			if img.FileName != half.FileName || img.Half == HalfNone {
+				continue
+			}
+			if img.Half == HalfLeft {
+				merged.Left = img.Left
+			} else {
+				merged.Right = img.Right
+			}
+			if img == half {
+				view.Images[imageIndex] = &merged
+				view.ClearPanels()
+				view.SkewDetected = false
+				continue
+			}
+			view.Images = append(view.Images[:imageIndex], view.Images[imageIndex+1:]...)
+			imageIndex--
+			if len(view.Images) == 0 {
+				album.Views = append(album.Views[:viewIndex], album.Views[viewIndex+1:]...)
+				if viewIndex < album.CurrentViewIndex {
+					album.CurrentViewIndex--
+				}
+				viewIndex--
+			}
+		}
+	}
+
+	// the whole image takes the place of the halves in the images of the album
+	position := -1
+	for index := 0; index < len(album.Images); index++ {
+		if img := album.Images[index]; img.FileName == half.FileName && img.Half != HalfNone {
+			if position < 0 {
+				position = index
+			}
+			album.Images = append(album.Images[:index], album.Images[index+1:]...)
+			index--
+		}
+	}
+	if position >= 0 {
+		album.Images = append(album.Images[:position], append([]*ImageData{&merged}, album.Images[position:]...)...)
+	}
+
+	g.notify("Spread merged")
+	g.needsRefresh = true
+}
+
+// changeOverlap changes the part of the width both halves of the spreads of the current view extend past the fold
+func (g *GogoReader) changeOverlap(delta float64) {
+	changed := false
+	for _, imgData := range album.GetCurrentView().Images {
+		if imgData.Half == HalfNone {
+			continue
+		}
+		overlap := math.Round(math.Max(0, math.Min(maxOverlap, imgData.Overlap+delta))*1000) / 1000
+		for _, img := range album.halves(imgData.FileName) {
+			img.Overlap = overlap
+		}
+		for _, view := range album.Views {
+			for _, img := range view.Images {
+				if img.FileName == imgData.FileName {
+					view.imageSprites = nil
+					view.ClearPanels()
+				}
+			}
+		}
+		g.notify("Spread overlap : %.1f%%", overlap*100)
+		changed = true
+	}
+	if !changed {
+		g.notify("The current page is not a split spread")
+		return
+	}
+	g.needsRefresh = true
+}
@@ -0,0 +1,93 @@
+package thumbnails
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"sort"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// encodePNG encodes img as a PNG and inserts the given key/value pairs as tEXt
+// chunks right after the IHDR chunk, as required by the freedesktop spec.
+func encodePNG(w io.Writer, img image.Image, text map[string]string) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	// signature + IHDR (length, type, 13 bytes of data, crc)
+	ihdrEnd := len(pngSignature) + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd {
+		return errors.New("invalid PNG data")
+	}
+	if _, err := w.Write(data[:ihdrEnd]); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(text))
+	for key := range text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := writeChunk(w, "tEXt", []byte(key+"\x00"+text[key])); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(data[ihdrEnd:])
+	return err
+}
+
+func writeChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], chunkType)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPNGText returns the tEXt chunks found in the given PNG data.
+func readPNGText(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	text := make(map[string]string)
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		if length < 0 || pos+8+length+4 > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunk := data[pos+8 : pos+8+length]
+		switch chunkType {
+		case "tEXt":
+			if sep := bytes.IndexByte(chunk, 0); sep > 0 {
+				text[string(chunk[:sep])] = string(chunk[sep+1:])
+			}
+		case "IDAT", "IEND":
+			// text chunks describing thumbnails are always located before the image data
+			return text, nil
+		}
+		pos += 8 + length + 4
+	}
+	return text, nil
+}
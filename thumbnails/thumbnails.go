@@ -0,0 +1,229 @@
+package thumbnails
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// Size is the maximum dimension of a thumbnail, as defined by the freedesktop
+// thumbnail specification.
+type Size int
+
+const (
+	Normal  Size = 128
+	Large   Size = 256
+	XLarge  Size = 512
+	XXLarge Size = 1024
+)
+
+// Dir returns the name of the cache sub folder used for thumbnails of this size.
+func (s Size) Dir() string {
+	switch s {
+	case Large:
+		return "large"
+	case XLarge:
+		return "x-large"
+	case XXLarge:
+		return "xx-large"
+	}
+	return "normal"
+}
+
+// ParseSize converts a size folder name (normal, large, x-large, xx-large) to a Size.
+func ParseSize(name string) (Size, error) {
+	for _, size := range []Size{Normal, Large, XLarge, XXLarge} {
+		if size.Dir() == name {
+			return size, nil
+		}
+	}
+	return Normal, fmt.Errorf("unknown thumbnail size %s", name)
+}
+
+// Cache stores thumbnails on disk, using the same layout and metadata as the
+// freedesktop thumbnail specification (<folder>/<size>/<md5 of uri>.png).
+type Cache struct {
+	Folder   string
+	MaxBytes int64
+
+	mu sync.Mutex
+}
+
+func NewCache(folder string, maxBytes int64) *Cache {
+	return &Cache{Folder: folder, MaxBytes: maxBytes}
+}
+
+// FileURI returns the URI identifying a loose image file, as expected by the
+// freedesktop thumbnail specification.
+func FileURI(fileName string) (string, error) {
+	absolute, err := filepath.Abs(fileName)
+	if err != nil {
+		return "", err
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(absolute)}
+	return u.String(), nil
+}
+
+// EntryURI returns the URI identifying an entry of an album, the album being
+// identified by the MD5 of its archive.
+func EntryURI(albumMD5 string, entryName string) string {
+	u := url.URL{Scheme: "gogoreader", Host: albumMD5, Path: "/" + entryName}
+	return u.String()
+}
+
+// Path returns the file used to cache the thumbnail of the given URI.
+func (c *Cache) Path(uri string, size Size) string {
+	return path.Join(c.Folder, size.Dir(), fmt.Sprintf("%x.png", md5.Sum([]byte(uri))))
+}
+
+// Get returns the cached thumbnail for this uri, if it exists and is not older
+// than mtime (seconds since epoch, 0 for entries that never change).
+func (c *Cache) Get(uri string, mtime int64, size Size) (image.Image, bool) {
+	fileName := c.Path(uri, size)
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, false
+	}
+	text, err := readPNGText(data)
+	if err != nil || text["Thumb::URI"] != uri || text["Thumb::MTime"] != strconv.FormatInt(mtime, 10) {
+		return nil, false
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	// mark the thumbnail as recently used, so that it survives the next cleanup
+	now := time.Now()
+	os.Chtimes(fileName, now, now)
+	return img, true
+}
+
+// Put scales img down to the requested size and stores it in the cache.
+func (c *Cache) Put(uri string, mtime int64, size Size, img image.Image) (image.Image, error) {
+	thumbnail := img
+	if img.Bounds().Dx() > int(size) || img.Bounds().Dy() > int(size) {
+		thumbnail = imaging.Fit(img, int(size), int(size), imaging.Lanczos)
+	}
+
+	fileName := c.Path(uri, size)
+	err := os.MkdirAll(path.Dir(fileName), 0700)
+	if err != nil {
+		return thumbnail, err
+	}
+
+	text := map[string]string{
+		"Thumb::URI":           uri,
+		"Thumb::MTime":         strconv.FormatInt(mtime, 10),
+		"Thumb::Image::Width":  strconv.Itoa(img.Bounds().Dx()),
+		"Thumb::Image::Height": strconv.Itoa(img.Bounds().Dy()),
+		"Software":             "gogoreader",
+	}
+
+	// write to a temporary file first so that other readers never see a partial thumbnail
+	tmp, err := ioutil.TempFile(path.Dir(fileName), "gogoreader-*.png")
+	if err != nil {
+		return thumbnail, err
+	}
+	err = encodePNG(tmp, thumbnail, text)
+	tmp.Close()
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), fileName)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return thumbnail, err
+}
+
+// GetOrCreate returns the cached thumbnail for this uri, creating it from the
+// image returned by load when it is missing or outdated.
+func (c *Cache) GetOrCreate(uri string, mtime int64, size Size, load func() (image.Image, error)) (image.Image, error) {
+	if img, ok := c.Get(uri, mtime, size); ok {
+		return img, nil
+	}
+	img, err := load()
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := c.Put(uri, mtime, size, img)
+	if err != nil {
+		// the thumbnail is still usable, even if it could not be saved
+		log.Printf("Unable to save thumbnail for %s - %s\n", uri, err.Error())
+	}
+	return thumbnail, nil
+}
+
+// Cleanup removes the least recently used thumbnails until the cache fits
+// in MaxBytes. A MaxBytes of 0 means no limit.
+func (c *Cache) Cleanup() error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cached []os.FileInfo
+	var cachedPaths []string
+	var total int64
+	for _, size := range []Size{Normal, Large, XLarge, XXLarge} {
+		folder := path.Join(c.Folder, size.Dir())
+		infos, err := ioutil.ReadDir(folder)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+			cached = append(cached, info)
+			cachedPaths = append(cachedPaths, path.Join(folder, info.Name()))
+			total += info.Size()
+		}
+	}
+
+	if total <= c.MaxBytes {
+		return nil
+	}
+
+	order := make([]int, len(cached))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return cached[order[i]].ModTime().Before(cached[order[j]].ModTime())
+	})
+
+	removed := 0
+	for _, i := range order {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(cachedPaths[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= cached[i].Size()
+		removed++
+	}
+	log.Printf("Removed %d thumbnails from %s\n", removed, c.Folder)
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package thumbnails
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newCache(t *testing.T) *Cache {
+	folder, err := ioutil.TempDir("", "thumbnails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(folder) })
+	return NewCache(folder, 0)
+}
+
+func TestPNGText(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 3))
+	img.SetGray(1, 1, color.Gray{Y: 200})
+
+	var buf bytes.Buffer
+	text := map[string]string{"Thumb::URI": "file:///tmp/a.png", "Thumb::MTime": "42"}
+	if err := encodePNG(&buf, img, text); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readPNGText(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(text) {
+		t.Errorf("read %d text chunks, want %d", len(read), len(text))
+	}
+	for key, value := range text {
+		if read[key] != value {
+			t.Errorf("%s = %q, want %q", key, read[key], value)
+		}
+	}
+
+	// the chunks must not prevent the standard decoder from reading the image
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("decoded bounds %v, want %v", decoded.Bounds(), img.Bounds())
+	}
+	if y := color.GrayModel.Convert(decoded.At(1, 1)).(color.Gray).Y; y != 200 {
+		t.Errorf("decoded pixel %d, want 200", y)
+	}
+
+	if _, err := readPNGText([]byte("GIF89a")); err == nil {
+		t.Error("readPNGText accepted data that is not a PNG")
+	}
+}
+
+func TestCachePutGet(t *testing.T) {
+	cache := newCache(t)
+	uri := EntryURI("0123456789abcdef", "page 1.jpg")
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+
+	thumbnail, err := cache.Put(uri, 10, Normal, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := thumbnail.Bounds().Size(); size != image.Pt(128, 64) {
+		t.Errorf("thumbnail size %v, want (128,64)", size)
+	}
+
+	cached, ok := cache.Get(uri, 10, Normal)
+	if !ok {
+		t.Fatal("thumbnail not found in the cache")
+	}
+	if cached.Bounds().Size() != thumbnail.Bounds().Size() {
+		t.Errorf("cached size %v, want %v", cached.Bounds().Size(), thumbnail.Bounds().Size())
+	}
+
+	data, err := ioutil.ReadFile(cache.Path(uri, Normal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := readPNGText(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text["Thumb::Image::Width"] != "600" || text["Thumb::Image::Height"] != "300" {
+		t.Errorf("original size %sx%s, want 600x300", text["Thumb::Image::Width"], text["Thumb::Image::Height"])
+	}
+
+	if _, ok := cache.Get(uri, 11, Normal); ok {
+		t.Error("outdated thumbnail returned")
+	}
+	if _, ok := cache.Get(uri, 10, Large); ok {
+		t.Error("thumbnail returned for another size")
+	}
+
+	// another uri colliding on the same file must not get this thumbnail
+	other := EntryURI("0123456789abcdef", "page 2.jpg")
+	if err := os.Rename(cache.Path(uri, Normal), cache.Path(other, Normal)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(other, 10, Normal); ok {
+		t.Error("thumbnail of another uri returned")
+	}
+}
+
+func TestCacheGetOrCreate(t *testing.T) {
+	cache := newCache(t)
+	uri := EntryURI("0123456789abcdef", "cover.png")
+
+	loads := 0
+	load := func() (image.Image, error) {
+		loads++
+		return image.NewRGBA(image.Rect(0, 0, 50, 80)), nil
+	}
+	for i := 0; i < 3; i++ {
+		img, err := cache.GetOrCreate(uri, 0, Normal, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size != image.Pt(50, 80) {
+			t.Errorf("thumbnail size %v, want (50,80)", size)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("image loaded %d times, want 1", loads)
+	}
+
+	failure := errors.New("unreadable")
+	_, err := cache.GetOrCreate(EntryURI("0123456789abcdef", "broken.png"), 0, Normal, func() (image.Image, error) {
+		return nil, failure
+	})
+	if err != failure {
+		t.Errorf("error %v, want %v", err, failure)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for _, size := range []Size{Normal, Large, XLarge, XXLarge} {
+		parsed, err := ParseSize(size.Dir())
+		if err != nil || parsed != size {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", size.Dir(), parsed, err, size)
+		}
+	}
+	if _, err := ParseSize("huge"); err == nil {
+		t.Error("ParseSize accepted an unknown size")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/pixel"
+)
+
+const (
+	TransitionNone  = "none"
+	TransitionFade  = "fade"
+	TransitionSlide = "slide"
+	TransitionCurl  = "curl"
+
+	// transitions are disabled when drawing a frame takes longer than this
+	slowFrameDuration = time.Second / 30
+)
+
+// drawnSprite is a sprite with the matrix it was drawn with
+type drawnSprite struct {
+	sprite *pixel.Sprite
+	matrix pixel.Matrix
+}
+
+// transition animates the change from the previously displayed view to the current one
+type transition struct {
+	from    []drawnSprite
+	start   time.Time
+	forward bool
+	active  bool
+}
+
+// transitionsEnabled returns false when transitions are disabled in the preferences,
+// during slideshows and when the frames take too long to draw
+func (g *GogoReader) transitionsEnabled() bool {
+	if g.preferences.Transition == "" || g.preferences.Transition == TransitionNone || g.preferences.TransitionDuration <= 0 {
+		return false
+	}
+	return !g.slideshow.running && g.averageFrameDuration < slowFrameDuration
+}
+
+// startTransition keeps the last frame of the current view to animate the change to the next view
+func (g *GogoReader) startTransition(forward bool) {
+	g.transition.active = g.transitionsEnabled() && len(g.lastFrame) > 0
+	g.transition.from = g.lastFrame
+	g.transition.start = time.Now()
+	g.transition.forward = forward
+}
+
+// updateFrameDuration keeps a moving average of the time taken by each frame
+func (g *GogoReader) updateFrameDuration() {
+	now := time.Now()
+	if !g.lastFrameTime.IsZero() {
+		g.averageFrameDuration = (g.averageFrameDuration*9 + now.Sub(g.lastFrameTime)) / 10
+	}
+	g.lastFrameTime = now
+}
+
+// drawSprites draws the sprites of the current view, animating the transition from the previous view if needed
+func (g *GogoReader) drawSprites(sprites []drawnSprite) {
+	g.lastFrame = sprites
+
+	progress := 1.0
+	if g.transition.active {
+		progress = time.Since(g.transition.start).Seconds() / g.preferences.TransitionDuration
+		if progress >= 1 {
+			g.transition.active = false
+			g.transition.from = nil
+		}
+	}
+	if !g.transition.active {
+		for _, drawn := range sprites {
+			drawn.sprite.Draw(g.win, drawn.matrix)
+		}
+		return
+	}
+
+	// smooth start and end of the animation
+	eased := (1 - math.Cos(progress*math.Pi)) / 2
+
+	// direction in which the pages move
+	direction := -1.0
+	if g.transition.forward == album.RightToLeft {
+		direction = 1.0
+	}
+
+	switch g.preferences.Transition {
+	case TransitionFade:
+		for _, drawn := range g.transition.from {
+			drawn.sprite.DrawColorMask(g.win, drawn.matrix, pixel.Alpha(1-eased))
+		}
+		for _, drawn := range sprites {
+			drawn.sprite.DrawColorMask(g.win, drawn.matrix, pixel.Alpha(eased))
+		}
+	case TransitionSlide:
+		for _, drawn := range g.transition.from {
+			drawn.sprite.Draw(g.win, drawn.matrix.Moved(pixel.V(direction*eased*g.size.X, 0)))
+		}
+		for _, drawn := range sprites {
+			drawn.sprite.Draw(g.win, drawn.matrix.Moved(pixel.V(-direction*(1-eased)*g.size.X, 0)))
+		}
+	default:
+		// curl : the previous page folds toward the side the reader is going to, getting darker
+		for _, drawn := range sprites {
+			drawn.sprite.Draw(g.win, drawn.matrix)
+		}
+		pivot := pixel.V(0, g.size.Y/2)
+		if direction > 0 {
+			pivot.X = g.size.X
+		}
+		shade := 1 - eased*0.6
+		for _, drawn := range g.transition.from {
+			matrix := drawn.matrix.ScaledXY(pivot, pixel.V(math.Max(1-eased, 0.001), 1))
+			drawn.sprite.DrawColorMask(g.win, matrix, pixel.RGB(shade, shade, shade))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+)
+
+// InputDialog is a single line text input, displayed in the middle of the window
+type InputDialog struct {
+	Prompt string
+	Value  string
+
+	// Accept returns true for the runes that can be typed in the dialog, any rune is accepted when nil
+	Accept func(r rune) bool
+}
+
+func NewInputDialog(prompt string, accept func(r rune) bool) *InputDialog {
+	return &InputDialog{Prompt: prompt, Accept: accept}
+}
+
+// Type appends the typed runes to the value of the dialog
+func (d *InputDialog) Type(typed string) {
+	for _, r := range typed {
+		if d.Accept == nil || d.Accept(r) {
+			d.Value += string(r)
+		}
+	}
+}
+
+// DeleteLast removes the last rune of the value of the dialog
+func (d *InputDialog) DeleteLast() {
+	runes := []rune(d.Value)
+	if len(runes) > 0 {
+		d.Value = string(runes[:len(runes)-1])
+	}
+}
+
+func (d *InputDialog) Draw(target pixel.Target, atlas *text.Atlas, bounds pixel.Rect) {
+	textScale := 2.0
+	dialogText := text.New(pixel.ZV, atlas)
+	fmt.Fprintf(dialogText, "%s %s_", d.Prompt, d.Value)
+
+	size := dialogText.Bounds().Size().Scaled(textScale)
+	padding := 10.0
+	origin := bounds.Center().Sub(size.Scaled(0.5))
+
+	imd := imdraw.New(nil)
+	imd.Color = color.RGBA{30, 30, 30, 220}
+	imd.Push(origin.Sub(pixel.V(padding, padding)), origin.Add(size).Add(pixel.V(padding, padding)))
+	imd.Rectangle(0)
+	imd.Color = color.RGBA{200, 200, 200, 255}
+	imd.Push(origin.Sub(pixel.V(padding, padding)), origin.Add(size).Add(pixel.V(padding, padding)))
+	imd.Rectangle(1)
+	imd.Draw(target)
+
+	dialogText.Draw(target, pixel.IM.Scaled(pixel.ZV, textScale).Moved(origin.Sub(dialogText.Bounds().Min.Scaled(textScale))))
+}
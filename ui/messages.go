@@ -1,10 +1,74 @@
 package ui
 
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+)
+
+// duration of the fade out at the end of the life of a message, in seconds
+const fadeOutDuration = 0.5
+
 type Message struct {
 	Message string
+	IsError bool
 	timeout float64
+	created time.Time
 }
 
 func NewMessage(message string, timeoutInSeconds float64) Message {
-	return Message{Message: message, timeout: timeoutInSeconds}
+	return Message{Message: message, timeout: timeoutInSeconds, created: time.Now()}
+}
+
+func NewErrorMessage(err error, timeoutInSeconds float64) Message {
+	message := NewMessage(err.Error(), timeoutInSeconds)
+	message.IsError = true
+	return message
+}
+
+// Expired returns true when the message should not be displayed anymore
+func (m *Message) Expired(now time.Time) bool {
+	return now.Sub(m.created).Seconds() >= m.timeout
+}
+
+// Alpha returns the opacity of the message, which fades out before it expires
+func (m *Message) Alpha(now time.Time) float64 {
+	remaining := m.timeout - now.Sub(m.created).Seconds()
+	if remaining <= 0 {
+		return 0
+	}
+	if remaining < fadeOutDuration {
+		return remaining / fadeOutDuration
+	}
+	return 1
+}
+
+// Draw displays the message with its bottom left corner at the given position, it returns the height used
+func (m *Message) Draw(target pixel.Target, atlas *text.Atlas, position pixel.Vec, now time.Time) float64 {
+	textScale := 2.0
+	padding := 8.0
+	alpha := m.Alpha(now)
+
+	messageText := text.New(pixel.ZV, atlas)
+	fmt.Fprint(messageText, m.Message)
+	size := messageText.Bounds().Size().Scaled(textScale).Add(pixel.V(2*padding, 2*padding))
+
+	background := pixel.RGBA{R: 0.12, G: 0.12, B: 0.12, A: 0.8}
+	if m.IsError {
+		background = pixel.RGBA{R: 0.6, G: 0.1, B: 0.1, A: 0.8}
+	}
+	imd := imdraw.New(nil)
+	imd.Color = background.Mul(pixel.Alpha(alpha))
+	imd.Push(position, position.Add(size))
+	imd.Rectangle(0)
+	imd.Draw(target)
+
+	textPosition := position.Add(pixel.V(padding, padding)).Sub(messageText.Bounds().Min.Scaled(textScale))
+	messageText.DrawColorMask(target, pixel.IM.Scaled(pixel.ZV, textScale).Moved(textPosition), color.Alpha{A: uint8(alpha * 255)})
+
+	return size.Y
 }
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// Scrubber is a progress bar drawn at the bottom of the window, it shows the
+// current position among Count positions, with markers at given positions.
+type Scrubber struct {
+	Count    int
+	Position int
+	Markers  []int
+	Height   float64
+}
+
+// Bounds returns the rectangle occupied by the scrubber in the given window bounds
+func (s *Scrubber) Bounds(window pixel.Rect) pixel.Rect {
+	return pixel.R(window.Min.X, window.Min.Y, window.Max.X, window.Min.Y+s.Height)
+}
+
+// PositionAt returns the position matching the given point of the window
+func (s *Scrubber) PositionAt(window pixel.Rect, point pixel.Vec) int {
+	if s.Count <= 1 {
+		return 0
+	}
+	bounds := s.Bounds(window)
+	position := int(math.Floor((point.X - bounds.Min.X) / bounds.W() * float64(s.Count)))
+	if position < 0 {
+		return 0
+	}
+	if position >= s.Count {
+		return s.Count - 1
+	}
+	return position
+}
+
+// PositionX returns the horizontal coordinate of the center of the given position
+func (s *Scrubber) PositionX(window pixel.Rect, position int) float64 {
+	bounds := s.Bounds(window)
+	if s.Count == 0 {
+		return bounds.Min.X
+	}
+	return bounds.Min.X + (float64(position)+0.5)*bounds.W()/float64(s.Count)
+}
+
+func (s *Scrubber) Draw(imd *imdraw.IMDraw, window pixel.Rect) {
+	bounds := s.Bounds(window)
+
+	imd.Color = color.RGBA{30, 30, 30, 200}
+	imd.Push(bounds.Min, bounds.Max)
+	imd.Rectangle(0)
+
+	if s.Count > 0 {
+		progress := bounds.W() * float64(s.Position+1) / float64(s.Count)
+		imd.Color = color.RGBA{90, 140, 200, 220}
+		imd.Push(pixel.V(bounds.Min.X, bounds.Min.Y+s.Height/3), pixel.V(bounds.Min.X+progress, bounds.Max.Y-s.Height/3))
+		imd.Rectangle(0)
+	}
+
+	imd.Color = color.RGBA{230, 230, 230, 255}
+	for _, marker := range s.Markers {
+		x := bounds.Min.X + bounds.W()*float64(marker)/float64(s.Count)
+		imd.Push(pixel.V(x, bounds.Min.Y), pixel.V(x, bounds.Max.Y))
+		imd.Line(2)
+	}
+
+	// current position
+	x := s.PositionX(window, s.Position)
+	imd.Push(pixel.V(x, bounds.Min.Y+s.Height/2))
+	imd.Circle(s.Height/3, 0)
+}